@@ -0,0 +1,60 @@
+package v1
+
+import (
+	"bytes"
+	"net"
+	"strconv"
+	"text/template"
+)
+
+const (
+	// DefaultHostTemplate is used if pathfinder has neither host template nor cluster domain
+	DefaultHostTemplate = "{{.Name}}.{{.Namespace}}.svc:{{.Port}}"
+	// DefaultFQDNHostTemplate is used if pathfinder has cluster domain but no host template
+	DefaultFQDNHostTemplate = "{{.Name}}.{{.Namespace}}.svc.{{.ClusterDomain}}:{{.Port}}"
+)
+
+// HostTemplateVars are variables available to host templates
+type HostTemplateVars struct {
+	Name          string
+	Namespace     string
+	Port          int32
+	ClusterDomain string
+	// ClusterIP is the primary cluster ip, ClusterIPv4 and ClusterIPv6 are set for dual stack services
+	ClusterIP   string
+	ClusterIPv4 string
+	ClusterIPv6 string
+	Scheme      string
+}
+
+var hostTemplateFuncs = template.FuncMap{
+	// hostport joins host and port, ipv6 addresses are bracketed
+	"hostport": func(host string, port int32) string {
+		return net.JoinHostPort(host, strconv.Itoa(int(port)))
+	},
+}
+
+// EffectiveHostTemplate returns host template of pathfinder, defaults are used if not specified
+func (spec PathFinderSpec) EffectiveHostTemplate() string {
+	if len(spec.HostTemplate) > 0 {
+		return spec.HostTemplate
+	}
+	if len(spec.ClusterDomain) > 0 {
+		return DefaultFQDNHostTemplate
+	}
+	return DefaultHostTemplate
+}
+
+// ParseHostTemplate parses a host template, hostport function is available to it
+func ParseHostTemplate(text string) (*template.Template, error) {
+	return template.New("host").Funcs(hostTemplateFuncs).Option("missingkey=error").Parse(text)
+}
+
+// ExecuteHostTemplate renders a host from tmpl
+func ExecuteHostTemplate(tmpl *template.Template, vars HostTemplateVars) (string, error) {
+	buf := bytes.Buffer{}
+	if err := tmpl.Execute(&buf, vars); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
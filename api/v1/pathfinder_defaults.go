@@ -0,0 +1,69 @@
+package v1
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+const (
+	// DefaultRegion is used by pathfinders and services without region
+	DefaultRegion = "DEFAULT"
+	// RegionLabel is set to region of pathfinder, so that pathfinders can be selected by region
+	RegionLabel = "xmbsmdsj.com/region"
+	// Finalizer is added to pathfinders, so that controller cleans up before they are deleted
+	Finalizer = "xmbsmdsj.com/pathfinder"
+	// AutoProvisionLabel set to true on a namespace creates pathfinder of DEFAULT region in it
+	AutoProvisionLabel = "xmbsmdsj.com/auto-provision"
+	// AutoProvisionedLabel is set to true on pathfinders created by controller
+	AutoProvisionedLabel = "xmbsmdsj.com/auto-provisioned"
+	// ForceDeleteAnnotation set to true allows deleting pathfinders with active entries
+	ForceDeleteAnnotation = "XM-PathFinder-ForceDelete"
+)
+
+// DefaultClusterDomain is set to pathfinders without cluster domain, empty to leave them as they are.
+// It is detected when webhook is set up, see DetectClusterDomain
+var DefaultClusterDomain string
+
+// NormalizeRegion upper cases region, regions are case insensitive
+func NormalizeRegion(region string) string {
+	return strings.ToUpper(strings.TrimSpace(region))
+}
+
+// SameRegion tells if two regions are the same ignoring case
+func SameRegion(a string, b string) bool {
+	return NormalizeRegion(a) == NormalizeRegion(b)
+}
+
+// DetectClusterDomain finds cluster domain from search domains of resolv.conf, which look like
+// namespace.svc.cluster.local svc.cluster.local cluster.local in a pod. Empty if not found
+func DetectClusterDomain(resolvConf string) string {
+	f, err := os.Open(resolvConf)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || fields[0] != "search" {
+			continue
+		}
+		for _, domain := range fields[1:] {
+			if strings.HasPrefix(domain, "svc.") {
+				return strings.TrimSuffix(strings.TrimPrefix(domain, "svc."), ".")
+			}
+		}
+	}
+	return ""
+}
+
+func hasFinalizer(finalizers []string, finalizer string) bool {
+	for _, f := range finalizers {
+		if f == finalizer {
+			return true
+		}
+	}
+	return false
+}
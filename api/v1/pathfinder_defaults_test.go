@@ -0,0 +1,49 @@
+package v1
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDefault(t *testing.T) {
+	DefaultClusterDomain = "cluster.local"
+	defer func() { DefaultClusterDomain = "" }()
+
+	pf := PathFinder{}
+	pf.Default()
+	if pf.Spec.Region != DefaultRegion || pf.Spec.ClusterDomain != "cluster.local" ||
+		pf.Labels[RegionLabel] != DefaultRegion || len(pf.Finalizers) != 1 || pf.Spec.CollisionPolicy != CollisionOldestWins {
+		t.Fatalf("Unexpected defaults %v", pf)
+	}
+	pf.Default()
+	if len(pf.Finalizers) != 1 {
+		t.Fatalf("Finalizer should be added once, got %v", pf.Finalizers)
+	}
+
+	now := metav1.Now()
+	pf = PathFinder{ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &now}, Spec: PathFinderSpec{Region: "east-1", ClusterDomain: "Example.Org."}}
+	pf.Default()
+	if pf.Spec.Region != "EAST-1" || pf.Spec.ClusterDomain != "example.org" || len(pf.Finalizers) != 0 {
+		t.Fatalf("Unexpected defaults %v", pf)
+	}
+}
+
+func TestDetectClusterDomain(t *testing.T) {
+	f, err := ioutil.TempFile("", "resolv.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("nameserver 10.96.0.10\nsearch test.svc.cluster.local svc.cluster.local cluster.local\noptions ndots:5\n")
+	f.Close()
+
+	if domain := DetectClusterDomain(f.Name()); domain != "cluster.local" {
+		t.Fatalf("Expecting cluster.local, got %s", domain)
+	}
+	if domain := DetectClusterDomain(f.Name() + ".missing"); domain != "" {
+		t.Fatalf("Expecting empty domain, got %s", domain)
+	}
+}
@@ -17,6 +17,10 @@ limitations under the License.
 package v1
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -25,6 +29,7 @@ import (
 
 // PayloadKeyValPair organizes extra service information as key-value pairs
 type PayloadKeyValPair struct {
+	// +kubebuilder:validation:MinLength=1
 	Key string `json:"key"`
 	Val string `json:"val"`
 }
@@ -34,11 +39,173 @@ type Payload struct {
 	KeyValPairs []PayloadKeyValPair `json:"keyValPairs"`
 }
 
+// HealthCheckType is the protocol used to probe a service entry
+type HealthCheckType string
+
+const (
+	// HealthCheckTCP probes by opening a tcp connection
+	HealthCheckTCP HealthCheckType = "TCP"
+	// HealthCheckHTTP probes by sending GET request, 2xx and 3xx are healthy
+	HealthCheckHTTP HealthCheckType = "HTTP"
+)
+
+// HealthCheck configures active health checking of a service entry
+type HealthCheck struct {
+	Type HealthCheckType `json:"type"`
+	// Path of http health check
+	Path     string          `json:"path,omitempty"`
+	Interval metav1.Duration `json:"interval,omitempty"`
+	Timeout  metav1.Duration `json:"timeout,omitempty"`
+	// HealthyThreshold is the number of consecutive successes to become healthy
+	HealthyThreshold int32 `json:"healthyThreshold,omitempty"`
+	// UnhealthyThreshold is the number of consecutive failures to become unhealthy
+	UnhealthyThreshold int32 `json:"unhealthyThreshold,omitempty"`
+}
+
+// HealthState is the result of health checking
+type HealthState string
+
+const (
+	// HealthUnknown means entry is not probed yet
+	HealthUnknown HealthState = "Unknown"
+	// HealthHealthy means entry passed health check
+	HealthHealthy HealthState = "Healthy"
+	// HealthUnhealthy means entry failed health check
+	HealthUnhealthy HealthState = "Unhealthy"
+)
+
+// EntryState is the lifecycle state of a service entry
+type EntryState string
+
+const (
+	// EntryActive entries are open for new selections, empty state is also active
+	EntryActive EntryState = "Active"
+	// EntryDraining entries belong to deactivated or deleted services. Clients stop
+	// selecting them but keep existing connections until grace period ends
+	EntryDraining EntryState = "Draining"
+	// EntryRemoved entries are no longer in pathfinder status
+	EntryRemoved EntryState = "Removed"
+)
+
 // ServiceEntry is one single entry for a service, which may contain multiple hosts
 type ServiceEntry struct {
 	ServiceName string  `json:"serviceName"`
 	ServiceHost string  `json:"serviceHosts"`
 	Payload     Payload `json:"payload,omitempty"`
+	// ExternalHost are comma separated addresses reachable from outside the cluster,
+	// only published for NodePort and LoadBalancer services if pathfinder enables it
+	ExternalHost string `json:"externalHosts,omitempty"`
+
+	// Protocol is the transport protocol of the port, TCP, UDP or SCTP
+	Protocol string `json:"protocol,omitempty"`
+	// AppProtocol is the application protocol of the port
+	AppProtocol string `json:"appProtocol,omitempty"`
+	// TargetPort is the port number or name on pods
+	TargetPort string `json:"targetPort,omitempty"`
+	// Scheme tells clients how to talk to the entry, such as http, https or grpc
+	Scheme string `json:"scheme,omitempty"`
+	// KubeServiceName and Port are name and port of the kubernetes service of the entry,
+	// registration name may differ from service name. Empty for static entries
+	KubeServiceName string `json:"kubeServiceName,omitempty"`
+	Port            int32  `json:"port,omitempty"`
+
+	// HealthCheck is set if health checking is enabled for this entry
+	HealthCheck *HealthCheck `json:"healthCheck,omitempty"`
+	// Health is empty if health checking is disabled
+	Health HealthState `json:"health,omitempty"`
+
+	// ReadyEndpoints is only counted if readiness gating is enabled for the service
+	ReadyEndpoints *int32 `json:"readyEndpoints,omitempty"`
+	// NotReady is true if service has less ready endpoints than required
+	NotReady bool `json:"notReady,omitempty"`
+
+	// Static is true if entry is declared in pathfinder spec instead of discovered from a service
+	Static bool `json:"static,omitempty"`
+
+	State         EntryState   `json:"state,omitempty"`
+	DrainingSince *metav1.Time `json:"drainingSince,omitempty"`
+	// Reason explains why entry is draining
+	Reason string `json:"reason,omitempty"`
+}
+
+const (
+	// ReasonServiceDeleted means service of the entry is deleted
+	ReasonServiceDeleted = "ServiceDeleted"
+	// ReasonDeactivated means service of the entry is deactivated
+	ReasonDeactivated = "Deactivated"
+	// ReasonRegionChanged means service of the entry is moved to another region
+	ReasonRegionChanged = "RegionChanged"
+	// ReasonNotReady means service of the entry does not have enough ready endpoints
+	ReasonNotReady = "NotReady"
+	// ReasonStaticEntryRemoved means static entry is removed from pathfinder spec
+	ReasonStaticEntryRemoved = "StaticEntryRemoved"
+)
+
+// Tombstone records an entry recently removed from pathfinder
+type Tombstone struct {
+	ServiceName string      `json:"serviceName"`
+	RemovedAt   metav1.Time `json:"removedAt"`
+	Reason      string      `json:"reason,omitempty"`
+}
+
+// FlapState tracks changes of an entry, flapping entries are held as they were
+// published until they stop changing for a suppression period
+type FlapState struct {
+	ServiceName string `json:"serviceName"`
+	// Changes observed since WindowStart
+	Changes     int32       `json:"changes"`
+	WindowStart metav1.Time `json:"windowStart"`
+	// SuppressedUntil is set while changes of the entry are not published
+	SuppressedUntil *metav1.Time `json:"suppressedUntil,omitempty"`
+	// Observed is the last observed state of the entry, empty if entry is absent
+	Observed string `json:"observed,omitempty"`
+}
+
+// IsSuppressed tells if changes of the entry are held back at t
+func (f FlapState) IsSuppressed(t time.Time) bool {
+	return f.SuppressedUntil != nil && t.Before(f.SuppressedUntil.Time)
+}
+
+const (
+	// ConditionDegraded is true if pathfinder status is held back from the services of its region
+	ConditionDegraded = "Degraded"
+	// ReasonPanicThreshold means too many entries would be dropped in one reconcile
+	ReasonPanicThreshold = "PanicThreshold"
+	// ReasonReconciled means status reflects services of the region
+	ReasonReconciled = "Reconciled"
+
+	// ConditionNameCollision is true if services of the region register entries of the same name,
+	// reason of a true condition is the collision policy applied
+	ConditionNameCollision = "NameCollision"
+	// ReasonNoCollision means entry names of the region are unique
+	ReasonNoCollision = "NoCollision"
+)
+
+// CollisionPolicy decides which services get entries when they register entries of the same name
+// +kubebuilder:validation:Enum=OldestWins;RejectNewest;MergeHosts
+type CollisionPolicy string
+
+const (
+	// CollisionOldestWins publishes entry of the oldest service
+	CollisionOldestWins CollisionPolicy = "OldestWins"
+	// CollisionRejectNewest rejects services colliding with older ones at admission,
+	// entries of the oldest service are published if admission is bypassed
+	CollisionRejectNewest CollisionPolicy = "RejectNewest"
+	// CollisionMergeHosts publishes one entry with hosts of all services, oldest first
+	CollisionMergeHosts CollisionPolicy = "MergeHosts"
+)
+
+// StaticEntry declares an entry of a service outside the cluster, such as an external database
+type StaticEntry struct {
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// Host is a domain name or an ip address
+	// +kubebuilder:validation:MinLength=1
+	Host string `json:"host"`
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port    int32   `json:"port"`
+	Payload Payload `json:"payload,omitempty"`
 }
 
 // PathFinderSpec defines the desired state of PathFinder
@@ -46,9 +213,53 @@ type PathFinderSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
 
-	// Foo is an example field of PathFinder. Edit PathFinder_types.go to remove/update
+	// ClusterDomain is a dns subdomain like cluster.local
+	// +kubebuilder:validation:MaxLength=253
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`
 	ClusterDomain string `json:"clusterDomain,omitempty"`
-	Region        string `json:"region"`
+	// Region is a dns label, case insensitive. It is immutable
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9]([-A-Za-z0-9]*[A-Za-z0-9])?$`
+	Region string `json:"region"`
+	// HostTemplate is a go template rendering hosts of service entries, see HostTemplateVars.
+	// Fully qualified names are used by default if ClusterDomain is set
+	HostTemplate string `json:"hostTemplate,omitempty"`
+	// ExternalAddresses publishes external hosts of NodePort and LoadBalancer services
+	ExternalAddresses bool `json:"externalAddresses,omitempty"`
+
+	// StaticEntries are published along with entries discovered from services,
+	// discovered entries take precedence over static entries of the same name
+	StaticEntries []StaticEntry `json:"staticEntries,omitempty"`
+
+	// Paused freezes service entries, changes are computed but not published until unpaused
+	Paused      bool   `json:"paused,omitempty"`
+	PauseReason string `json:"pauseReason,omitempty"`
+	// PausedUntil expires pause, pathfinder is paused indefinitely if not set
+	PausedUntil *metav1.Time `json:"pausedUntil,omitempty"`
+
+	// CollisionPolicy applies to services registering entries of the same name, OldestWins by default
+	CollisionPolicy CollisionPolicy `json:"collisionPolicy,omitempty"`
+}
+
+// EffectiveCollisionPolicy returns collision policy, OldestWins if not set
+func (spec PathFinderSpec) EffectiveCollisionPolicy() CollisionPolicy {
+	if len(spec.CollisionPolicy) == 0 {
+		return CollisionOldestWins
+	}
+	return spec.CollisionPolicy
+}
+
+// IsPaused tells if pathfinder is paused at t
+func (spec PathFinderSpec) IsPaused(t time.Time) bool {
+	return spec.Paused && (spec.PausedUntil == nil || t.Before(spec.PausedUntil.Time))
+}
+
+// PendingChanges summarizes changes of entries held back while pathfinder is paused
+type PendingChanges struct {
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+	Changed []string `json:"changed,omitempty"`
 }
 
 // PathFinderStatus defines the observed state of PathFinder
@@ -56,8 +267,18 @@ type PathFinderStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
 	ServiceEntries []ServiceEntry `json:"serviceEntries,omitempty"`
+	// Tombstones of entries removed recently, kept for a retention window
+	Tombstones []Tombstone `json:"tombstones,omitempty"`
+	// Flaps of entries changing recently, see FlapState
+	Flaps []FlapState `json:"flaps,omitempty"`
+	// Pending changes of a paused pathfinder, applied at once when unpaused
+	Pending *PendingChanges `json:"pending,omitempty"`
+	// Conditions of pathfinder, see ConditionDegraded
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
+// +genclient
+// +genclient:noStatus
 // +kubebuilder:object:root=true
 // +kubebuilder:resource:shortName=pf;
 
@@ -89,6 +310,71 @@ func (status PathFinderStatus) FindServiceEntry(name string) (ServiceEntry, bool
 	return ServiceEntry{}, false
 }
 
+// FindTombstone find tombstone of a recently removed entry by name
+func (status PathFinderStatus) FindTombstone(name string) (Tombstone, bool) {
+	for _, t := range status.Tombstones {
+		if t.ServiceName == name {
+			return t, true
+		}
+	}
+	return Tombstone{}, false
+}
+
+// FindFlap find flap state of an entry by name
+func (status PathFinderStatus) FindFlap(name string) (FlapState, bool) {
+	for _, f := range status.Flaps {
+		if f.ServiceName == name {
+			return f, true
+		}
+	}
+	return FlapState{}, false
+}
+
+// IsHealthy is false only if entry failed health check
+func (entry ServiceEntry) IsHealthy() bool {
+	return entry.Health != HealthUnhealthy
+}
+
+// IsReady is false if entry does not have enough ready endpoints
+func (entry ServiceEntry) IsReady() bool {
+	return !entry.NotReady
+}
+
+// IsDraining tells if entry is closed for new selections
+func (entry ServiceEntry) IsDraining() bool {
+	return entry.State == EntryDraining
+}
+
+// ConventionalHost builds name.namespace.svc:port from kubernetes service of entry,
+// false if entry does not record its service
+func (entry ServiceEntry) ConventionalHost(namespace string) (string, bool) {
+	if len(entry.KubeServiceName) == 0 || entry.Port == 0 || len(namespace) == 0 {
+		return "", false
+	}
+	return fmt.Sprintf("%s.%s.svc:%d", entry.KubeServiceName, namespace, entry.Port), true
+}
+
+// Hosts splits ServiceHost into hosts, multiple hosts are separated by comma
+func (entry ServiceEntry) Hosts() []string {
+	return splitHosts(entry.ServiceHost)
+}
+
+// ExternalHosts splits ExternalHost into hosts
+func (entry ServiceEntry) ExternalHosts() []string {
+	return splitHosts(entry.ExternalHost)
+}
+
+func splitHosts(joined string) []string {
+	hosts := make([]string, 0)
+	for _, h := range strings.Split(joined, ",") {
+		h = strings.TrimSpace(h)
+		if len(h) > 0 {
+			hosts = append(hosts, h)
+		}
+	}
+	return hosts
+}
+
 func init() {
 	SchemeBuilder.Register(&PathFinder{}, &PathFinderList{})
 }
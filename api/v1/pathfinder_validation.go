@@ -0,0 +1,133 @@
+package v1
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// sampleHostTemplateVars is used to check host templates render valid hosts
+var sampleHostTemplateVars = HostTemplateVars{
+	Name:          "sample",
+	Namespace:     "default",
+	Port:          8080,
+	ClusterDomain: "cluster.local",
+	ClusterIP:     "fd00::1",
+	ClusterIPv4:   "10.0.0.1",
+	ClusterIPv6:   "fd00::1",
+	Scheme:        "http",
+}
+
+// invalid wraps errs into an Invalid api error, nil if errs is empty
+func (r *PathFinder) invalid(errs field.ErrorList) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("PathFinder").GroupKind(), r.Name, errs)
+}
+
+// ValidateSpec validates spec of pathfinder
+func (r *PathFinder) ValidateSpec() field.ErrorList {
+	specPath := field.NewPath("spec")
+	errs := ValidateRegion(r.Spec.Region, specPath.Child("region"))
+	if len(r.Spec.ClusterDomain) > 0 {
+		for _, msg := range validation.IsDNS1123Subdomain(r.Spec.ClusterDomain) {
+			errs = append(errs, field.Invalid(specPath.Child("clusterDomain"), r.Spec.ClusterDomain, msg))
+		}
+	}
+	errs = append(errs, validateHostTemplate(r.Spec.EffectiveHostTemplate(), specPath.Child("hostTemplate"))...)
+	errs = append(errs, validateStaticEntries(r.Spec.StaticEntries, specPath.Child("staticEntries"))...)
+	switch r.Spec.EffectiveCollisionPolicy() {
+	case CollisionOldestWins, CollisionRejectNewest, CollisionMergeHosts:
+	default:
+		errs = append(errs, field.NotSupported(specPath.Child("collisionPolicy"), r.Spec.CollisionPolicy,
+			[]string{string(CollisionOldestWins), string(CollisionRejectNewest), string(CollisionMergeHosts)}))
+	}
+	if r.Spec.PausedUntil != nil && !r.Spec.Paused {
+		errs = append(errs, field.Invalid(specPath.Child("pausedUntil"), r.Spec.PausedUntil, "only allowed when paused"))
+	}
+	return errs
+}
+
+// ValidateSpecUpdate validates immutable fields of pathfinder
+func (r *PathFinder) ValidateSpecUpdate(old *PathFinder) field.ErrorList {
+	errs := field.ErrorList{}
+	if !SameRegion(old.Spec.Region, r.Spec.Region) {
+		errs = append(errs, field.Forbidden(field.NewPath("spec", "region"), "region is immutable, create another pathfinder instead"))
+	}
+	return errs
+}
+
+// ValidateRegion checks region is a dns label, case is ignored so that DEFAULT is valid
+func ValidateRegion(region string, fldPath *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	if len(region) == 0 {
+		return append(errs, field.Required(fldPath, ""))
+	}
+	for _, msg := range validation.IsDNS1123Label(strings.ToLower(region)) {
+		errs = append(errs, field.Invalid(fldPath, region, msg))
+	}
+	return errs
+}
+
+// validateHostTemplate checks host template parses, and renders host:port
+func validateHostTemplate(text string, fldPath *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	tmpl, err := ParseHostTemplate(text)
+	if err != nil {
+		return append(errs, field.Invalid(fldPath, text, err.Error()))
+	}
+	host, err := ExecuteHostTemplate(tmpl, sampleHostTemplateVars)
+	if err != nil {
+		return append(errs, field.Invalid(fldPath, text, err.Error()))
+	}
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		errs = append(errs, field.Invalid(fldPath, text, fmt.Sprintf("should render host:port, got %s", host)))
+	}
+	return errs
+}
+
+// validateStaticEntries checks static entries are named uniquely, with valid hosts, ports and payloads
+func validateStaticEntries(entries []StaticEntry, fldPath *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	names := make(map[string]bool)
+	for i, static := range entries {
+		idxPath := fldPath.Index(i)
+		if len(static.Name) == 0 {
+			errs = append(errs, field.Required(idxPath.Child("name"), ""))
+		} else if names[static.Name] {
+			errs = append(errs, field.Duplicate(idxPath.Child("name"), static.Name))
+		}
+		names[static.Name] = true
+		if net.ParseIP(static.Host) == nil {
+			for _, msg := range validation.IsDNS1123Subdomain(static.Host) {
+				errs = append(errs, field.Invalid(idxPath.Child("host"), static.Host, msg))
+			}
+		}
+		for _, msg := range validation.IsValidPortNum(int(static.Port)) {
+			errs = append(errs, field.Invalid(idxPath.Child("port"), static.Port, msg))
+		}
+		errs = append(errs, validatePayload(static.Payload, idxPath.Child("payload"))...)
+	}
+	return errs
+}
+
+// validatePayload checks payload keys are not empty and unique
+func validatePayload(payload Payload, fldPath *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	keys := make(map[string]bool)
+	for i, kv := range payload.KeyValPairs {
+		keyPath := fldPath.Child("keyValPairs").Index(i).Child("key")
+		if len(kv.Key) == 0 {
+			errs = append(errs, field.Required(keyPath, ""))
+		} else if keys[kv.Key] {
+			errs = append(errs, field.Duplicate(keyPath, kv.Key))
+		}
+		keys[kv.Key] = true
+	}
+	return errs
+}
@@ -0,0 +1,103 @@
+package v1
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestValidateSpec(t *testing.T) {
+	valid := PathFinderSpec{
+		Region:        "DEFAULT",
+		ClusterDomain: "cluster.local",
+		HostTemplate:  "{{hostport .ClusterIP .Port}}",
+		StaticEntries: []StaticEntry{
+			{Name: "db", Host: "db.example.com", Port: 5432},
+			{Name: "cache", Host: "fd00::1", Port: 6379, Payload: Payload{KeyValPairs: []PayloadKeyValPair{{Key: "k", Val: "v"}}}},
+		},
+	}
+	pf := PathFinder{Spec: valid}
+	if errs := pf.ValidateSpec(); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	until := metav1.Now()
+	cases := map[string]func(spec *PathFinderSpec){
+		"spec.region":                func(spec *PathFinderSpec) { spec.Region = "not_a_label" },
+		"spec.clusterDomain":         func(spec *PathFinderSpec) { spec.ClusterDomain = "Cluster Local" },
+		"spec.hostTemplate":          func(spec *PathFinderSpec) { spec.HostTemplate = "{{.Unknown}}:80" },
+		"spec.staticEntries[1].name": func(spec *PathFinderSpec) { spec.StaticEntries[1].Name = "db" },
+		"spec.staticEntries[0].host": func(spec *PathFinderSpec) { spec.StaticEntries[0].Host = "Not A Host" },
+		"spec.staticEntries[0].port": func(spec *PathFinderSpec) { spec.StaticEntries[0].Port = 0 },
+		"spec.pausedUntil":           func(spec *PathFinderSpec) { spec.PausedUntil = &until },
+		"spec.collisionPolicy":       func(spec *PathFinderSpec) { spec.CollisionPolicy = "NewestWins" },
+		"spec.staticEntries[1].payload.keyValPairs[1].key": func(spec *PathFinderSpec) {
+			spec.StaticEntries[1].Payload.KeyValPairs = append(spec.StaticEntries[1].Payload.KeyValPairs, PayloadKeyValPair{Key: "k"})
+		},
+	}
+	for path, mutate := range cases {
+		pf := PathFinder{Spec: *valid.DeepCopy()}
+		mutate(&pf.Spec)
+		errs := pf.ValidateSpec()
+		if len(errs) != 1 || errs[0].Field != path {
+			t.Fatalf("Expecting error of %s, got %v", path, errs)
+		}
+	}
+}
+
+func TestValidateSpecUpdate(t *testing.T) {
+	old := PathFinder{Spec: PathFinderSpec{Region: "DEFAULT"}}
+	pf := PathFinder{Spec: PathFinderSpec{Region: "DEFAULT", ClusterDomain: "cluster.local"}}
+	if errs := pf.ValidateSpecUpdate(&old); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	pf.Spec.Region = "OTHER"
+	errs := pf.ValidateSpecUpdate(&old)
+	if len(errs) != 1 || errs[0].Type != field.ErrorTypeForbidden {
+		t.Fatalf("Expecting region to be immutable, got %v", errs)
+	}
+}
+
+func TestValidateDelete(t *testing.T) {
+	pf := newTestPathFinder("a", "DEFAULT")
+	if err := pf.ValidateDelete(); err != nil {
+		t.Fatalf("Pathfinder without entries should be deleted, got %v", err)
+	}
+
+	pf.Status.ServiceEntries = []ServiceEntry{{ServiceName: "draining", State: EntryDraining}}
+	if err := pf.ValidateDelete(); err != nil {
+		t.Fatalf("Pathfinder with draining entries only should be deleted, got %v", err)
+	}
+
+	pf.Status.ServiceEntries = append(pf.Status.ServiceEntries, ServiceEntry{ServiceName: "active"})
+	if err := pf.ValidateDelete(); !apierrors.IsForbidden(err) {
+		t.Fatalf("Expecting forbidden, got %v", err)
+	}
+
+	pf.Annotations = map[string]string{ForceDeleteAnnotation: "true"}
+	if err := pf.ValidateDelete(); err != nil {
+		t.Fatalf("Force deletion should pass, got %v", err)
+	}
+}
+
+func TestValidateDeleteOfTerminatingNamespace(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	now := metav1.Now()
+	ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test", DeletionTimestamp: &now}}
+	pf := newTestPathFinder("a", "DEFAULT")
+	pf.Status.ServiceEntries = []ServiceEntry{{ServiceName: "active"}}
+
+	if err := pf.validateDelete(context.TODO(), fake.NewFakeClientWithScheme(scheme)); !apierrors.IsForbidden(err) {
+		t.Fatalf("Expecting forbidden, got %v", err)
+	}
+	if err := pf.validateDelete(context.TODO(), fake.NewFakeClientWithScheme(scheme, &ns)); err != nil {
+		t.Fatalf("Pathfinder of terminating namespace should be deleted, got %v", err)
+	}
+}
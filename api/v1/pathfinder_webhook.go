@@ -19,11 +19,13 @@ package v1
 import (
 	"context"
 	"fmt"
+	"strings"
 
-	"github.com/6BD-org/pathfinder/consts"
-	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
-	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -32,11 +34,15 @@ import (
 
 // log is for logging in this package.
 var pathfinderlog = logf.Log.WithName("pathfinder-resource")
-var k8sClient client.Client
-var hookScheme = runtime.NewScheme()
+
+// webhookClient and webhookReader are set up with manager, validators have no other way to reach them
+var webhookClient client.Client
+var webhookReader client.Reader
 
 // SetupWebhookWithManager Register webhooks so that controller can handle then on certain paths
 func (r *PathFinder) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	webhookClient = mgr.GetClient()
+	webhookReader = mgr.GetAPIReader()
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(r).
 		Complete()
@@ -49,90 +55,88 @@ func (r *PathFinder) SetupWebhookWithManager(mgr ctrl.Manager) error {
 
 var _ webhook.Defaulter = &PathFinder{}
 
-// Default implements webhook.Defaulter so a webhook will be registered for the type
+// Default implements webhook.Defaulter. It fills in region, cluster domain, collision policy,
+// region label and finalizer, and normalizes region
 func (r *PathFinder) Default() {
-	pathfinderlog.Info("default", "name", r.Name)
-	// TODO(user): fill in your defaulting logic.
+	if len(r.Spec.Region) == 0 {
+		r.Spec.Region = DefaultRegion
+	}
+	r.Spec.Region = NormalizeRegion(r.Spec.Region)
+	if len(r.Spec.ClusterDomain) == 0 {
+		r.Spec.ClusterDomain = DefaultClusterDomain
+	}
+	r.Spec.ClusterDomain = strings.ToLower(strings.TrimSuffix(r.Spec.ClusterDomain, "."))
+	r.Spec.CollisionPolicy = r.Spec.EffectiveCollisionPolicy()
+
+	if r.Labels == nil {
+		r.Labels = make(map[string]string)
+	}
+	r.Labels[RegionLabel] = r.Spec.Region
+
+	// Finalizers can't be added to pathfinders being deleted
+	if r.DeletionTimestamp == nil && !hasFinalizer(r.Finalizers, Finalizer) {
+		r.Finalizers = append(r.Finalizers, Finalizer)
+	}
 }
 
-// TODO(user): change verbs to "verbs=create;update;delete" if you want to enable deletion validation.
-// +kubebuilder:webhook:verbs=create;update,path=/validate-xmbsmdsj-com-v1-pathfinder,mutating=false,failurePolicy=fail,groups=xmbsmdsj.com,resources=pathfinders,versions=v1,name=vpathfinder.kb.io
+// +kubebuilder:webhook:verbs=create;update;delete,path=/validate-xmbsmdsj-com-v1-pathfinder,mutating=false,failurePolicy=fail,groups=xmbsmdsj.com,resources=pathfinders,versions=v1,name=vpathfinder.kb.io
 
 var _ webhook.Validator = &PathFinder{}
 
 // ValidateCreate implements webhook.Validator so a webhook will be registered for the type
 func (r *PathFinder) ValidateCreate() error {
 	pathfinderlog.Info("validate create", "name", r.Name)
-	// TODO(user): fill in your validation logic upon object creation.
-	err := r.CheckDuplication()
-	if err != nil {
+	if err := r.invalid(r.ValidateSpec()); err != nil {
 		return err
 	}
-	return nil
+	return r.ClaimRegion(context.TODO(), webhookClient, webhookReader)
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
 func (r *PathFinder) ValidateUpdate(old runtime.Object) error {
-	// TODO Optimize duplication check
 	oldPf, ok := old.(*PathFinder)
 	if !ok {
 		return fmt.Errorf("Fail to convert object to pathfinder")
 	}
-
-	if oldPf.Spec.Region != r.Spec.Region {
-		return r.CheckDuplication()
-	}
-
-	return nil
+	errs := r.ValidateSpecUpdate(oldPf)
+	errs = append(errs, r.ValidateSpec()...)
+	return r.invalid(errs)
 }
 
-// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type.
+// Pathfinders with active entries are only deleted with force delete annotation
 func (r *PathFinder) ValidateDelete() error {
 	pathfinderlog.Info("validate delete", "name", r.Name)
-
-	// TODO(user): fill in your validation logic upon object deletion.
-
-	return nil
+	return r.validateDelete(context.TODO(), webhookReader)
 }
 
-// CheckDuplication Check duplicated pathfinder in same namespace and same region
-func (r *PathFinder) CheckDuplication() error {
-	pfl := PathFinderList{}
-	err := getClient().List(context.TODO(), &pfl, client.InNamespace(r.Namespace))
-	if err != nil {
-		return err
+// validateDelete refuses deleting pathfinder with active entries, unless it is forced or its
+// namespace is being deleted. Entries held back by panic threshold are no longer backed by
+// services, and don't count as active
+func (r *PathFinder) validateDelete(ctx context.Context, reader client.Reader) error {
+	if r.Annotations[ForceDeleteAnnotation] == "true" {
+		return nil
+	}
+	if c := meta.FindStatusCondition(r.Status.Conditions, ConditionDegraded); c != nil &&
+		c.Status == metav1.ConditionTrue && c.Reason == ReasonPanicThreshold {
+		return nil
 	}
-	for _, pf := range pfl.Items {
-		if pf.Spec.Region == r.Spec.Region {
-			return errors.Errorf("Duplicated pathfinder in same region")
+	// Services of a namespace being deleted go away along with their pathfinders
+	if reader != nil {
+		ns := corev1.Namespace{}
+		if err := reader.Get(ctx, client.ObjectKey{Name: r.Namespace}, &ns); err == nil && ns.DeletionTimestamp != nil {
+			return nil
 		}
 	}
-	return nil
-}
-
-func getClient() client.Client {
-	var err error
-	if k8sClient == nil {
-		k8sClient, err = client.New(ctrl.GetConfigOrDie(), client.Options{
-			Scheme: hookScheme,
-		})
-		if err != nil {
-			panic(err)
+	active := 0
+	for _, entry := range r.Status.ServiceEntries {
+		if !entry.IsDraining() {
+			active++
 		}
-		return k8sClient
-	} else {
-		return k8sClient
 	}
-
-}
-
-func init() {
-	var err error
-
-	_ = clientgoscheme.AddToScheme(hookScheme)
-	_ = AddToScheme(hookScheme)
-
-	if err != nil {
-		pathfinderlog.Error(err, consts.ERR_WEBHOOK_INIT_FAIL)
+	if active == 0 {
+		return nil
 	}
+	return apierrors.NewForbidden(Resource("pathfinders"), r.Name,
+		fmt.Errorf("region %s still has %d active entries, annotate with %s=true to delete anyway", r.Spec.Region, active, ForceDeleteAnnotation))
 }
@@ -0,0 +1,168 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PayloadTag is the struct tag read by Decode.
+// `pathfinder:"key"` maps a field to payload key, `pathfinder:"key,required"`
+// fails decoding if key is missing, and `pathfinder:"-"` skips the field.
+// Fields without tag use field name as key
+const PayloadTag = "pathfinder"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Get returns value of key. If key presents more than once, the first one is returned
+func (p Payload) Get(key string) (string, bool) {
+	for _, kv := range p.KeyValPairs {
+		if kv.Key == key {
+			return kv.Val, true
+		}
+	}
+	return "", false
+}
+
+// AsMap converts payload to a map, the first value wins for duplicated keys
+func (p Payload) AsMap() map[string]string {
+	m := make(map[string]string, len(p.KeyValPairs))
+	for _, kv := range p.KeyValPairs {
+		if _, ok := m[kv.Key]; !ok {
+			m[kv.Key] = kv.Val
+		}
+	}
+	return m
+}
+
+// Decode fills struct pointed by into with payload values according to struct tags.
+// Strings, ints, uints, floats, bools and durations are converted from text,
+// other field types are decoded from JSON
+func (p Payload) Decode(into interface{}) error {
+	v := reflect.ValueOf(into)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("Payload can only be decoded into a non-nil struct pointer, got %T", into)
+	}
+	v = v.Elem()
+	t := v.Type()
+	values := p.AsMap()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if len(field.PkgPath) > 0 {
+			// unexported
+			continue
+		}
+		key, required := parsePayloadTag(field)
+		if key == "-" {
+			continue
+		}
+		val, ok := values[key]
+		if !ok {
+			if required {
+				return fmt.Errorf("Missing required payload key %s", key)
+			}
+			continue
+		}
+		if err := setPayloadField(v.Field(i), val); err != nil {
+			return fmt.Errorf("Invalid value %q of payload key %s: %v", val, key, err)
+		}
+	}
+	return nil
+}
+
+// Get returns payload value of key
+func (entry ServiceEntry) Get(key string) (string, bool) {
+	return entry.Payload.Get(key)
+}
+
+// AsMap converts payload of entry to a map
+func (entry ServiceEntry) AsMap() map[string]string {
+	return entry.Payload.AsMap()
+}
+
+// Decode decodes payload of entry into a struct, see Payload.Decode
+func (entry ServiceEntry) Decode(into interface{}) error {
+	return entry.Payload.Decode(into)
+}
+
+func parsePayloadTag(field reflect.StructField) (string, bool) {
+	tag, ok := field.Tag.Lookup(PayloadTag)
+	if !ok {
+		return field.Name, false
+	}
+	parts := strings.Split(tag, ",")
+	key := parts[0]
+	if len(key) == 0 {
+		key = field.Name
+	}
+	required := false
+	for _, opt := range parts[1:] {
+		if opt == "required" {
+			required = true
+		}
+	}
+	return key, required
+}
+
+func setPayloadField(f reflect.Value, val string) error {
+	if f.Type() == durationType {
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			return err
+		}
+		f.SetInt(int64(d))
+		return nil
+	}
+
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(val)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return err
+		}
+		f.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(val, 10, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(val, 10, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(val, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetFloat(n)
+	default:
+		return json.Unmarshal([]byte(val), f.Addr().Interface())
+	}
+	return nil
+}
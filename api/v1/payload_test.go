@@ -0,0 +1,58 @@
+package v1
+
+import (
+	"testing"
+	"time"
+)
+
+type testConfig struct {
+	Endpoint string            `pathfinder:"endpoint,required"`
+	Replicas int32             `pathfinder:"replicas"`
+	Secure   bool              `pathfinder:"secure"`
+	Timeout  time.Duration     `pathfinder:"timeout"`
+	Labels   map[string]string `pathfinder:"labels"`
+	Ignored  string            `pathfinder:"-"`
+	Weight   float64
+}
+
+func TestPayloadDecode(t *testing.T) {
+	entry := ServiceEntry{
+		ServiceName: "db",
+		Payload: Payload{KeyValPairs: []PayloadKeyValPair{
+			{Key: "endpoint", Val: "db:5432"},
+			{Key: "replicas", Val: "3"},
+			{Key: "secure", Val: "true"},
+			{Key: "timeout", Val: "1m30s"},
+			{Key: "labels", Val: `{"tier":"db"}`},
+			{Key: "Ignored", Val: "x"},
+			{Key: "Weight", Val: "0.5"},
+		}},
+	}
+
+	cfg := testConfig{}
+	if err := entry.Decode(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Endpoint != "db:5432" || cfg.Replicas != 3 || !cfg.Secure ||
+		cfg.Timeout != 90*time.Second || cfg.Labels["tier"] != "db" ||
+		cfg.Ignored != "" || cfg.Weight != 0.5 {
+		t.Fatalf("Unexpected decoded config %+v", cfg)
+	}
+
+	if v, ok := entry.Get("replicas"); !ok || v != "3" {
+		t.Fatalf("Unexpected value %s", v)
+	}
+
+	missing := Payload{KeyValPairs: []PayloadKeyValPair{{Key: "replicas", Val: "3"}}}
+	if err := missing.Decode(&cfg); err == nil {
+		t.Fatal("Expecting error for missing required key")
+	}
+
+	invalid := Payload{KeyValPairs: []PayloadKeyValPair{
+		{Key: "endpoint", Val: "db:5432"},
+		{Key: "replicas", Val: "three"},
+	}}
+	if err := invalid.Decode(&cfg); err == nil {
+		t.Fatal("Expecting error for invalid int")
+	}
+}
@@ -0,0 +1,137 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// RegionClaimGracePeriod protects a region claimed by a pathfinder which is not created yet.
+// Admission of a create finishes within webhook timeout, which is 30s at most
+const RegionClaimGracePeriod = 30 * time.Second
+
+// RegionLeasePrefix is the name prefix of region leases
+const RegionLeasePrefix = "pathfinder-region-"
+
+// RegionLeaseName is the name of lease claiming region for a pathfinder, lease names
+// are unique in a namespace, so are regions. Region is case insensitive
+func RegionLeaseName(region string) string {
+	return RegionLeasePrefix + strings.ToLower(region)
+}
+
+// NewRegionLease builds lease claiming region of pf
+func NewRegionLease(pf *PathFinder, now time.Time) *coordinationv1.Lease {
+	holder := pf.Name
+	acquireTime := metav1.NewMicroTime(now)
+	return &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      RegionLeaseName(pf.Spec.Region),
+			Namespace: pf.Namespace,
+		},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity: &holder,
+			AcquireTime:    &acquireTime,
+		},
+	}
+}
+
+// LeaseHolder returns name of pathfinder holding lease
+func LeaseHolder(lease *coordinationv1.Lease) string {
+	if lease.Spec.HolderIdentity == nil {
+		return ""
+	}
+	return *lease.Spec.HolderIdentity
+}
+
+// ClaimRegion makes pathfinder the only one of its region in namespace. Creating region lease
+// is atomic, so concurrent creates can't both succeed. Lease of a pathfinder no longer
+// holding the region is taken over with optimistic locking. Reads deciding a take over
+// go through reader, which is not cached. Leases are held by name, so pathfinders must
+// be named, generated names are assigned before validation
+func (r *PathFinder) ClaimRegion(ctx context.Context, c client.Client, reader client.Reader) error {
+	regionPath := field.NewPath("spec", "region")
+	now := time.Now()
+	if len(r.Name) == 0 {
+		return r.invalid(field.ErrorList{field.Required(field.NewPath("metadata", "name"), "name is required to claim region")})
+	}
+
+	lease := NewRegionLease(r, now)
+	err := c.Create(ctx, lease)
+	if apierrors.IsAlreadyExists(err) {
+		err = r.takeOverRegion(ctx, c, reader, now)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Pathfinders created before region leases are adopted by controller, which may not be done yet
+	pfl := PathFinderList{}
+	if err := reader.List(ctx, &pfl, client.InNamespace(r.Namespace)); err != nil {
+		return err
+	}
+	for _, pf := range pfl.Items {
+		if pf.Name != r.Name && strings.EqualFold(pf.Spec.Region, r.Spec.Region) {
+			// Lease is released, so that controller can recreate it for the existing pathfinder
+			if err := r.releaseRegion(ctx, c, reader); err != nil {
+				return err
+			}
+			return r.invalid(field.ErrorList{field.Duplicate(regionPath, r.Spec.Region)})
+		}
+	}
+	return nil
+}
+
+// releaseRegion deletes region lease if it is still held by pathfinder
+func (r *PathFinder) releaseRegion(ctx context.Context, c client.Client, reader client.Reader) error {
+	lease := coordinationv1.Lease{}
+	key := client.ObjectKey{Namespace: r.Namespace, Name: RegionLeaseName(r.Spec.Region)}
+	if err := reader.Get(ctx, key, &lease); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if LeaseHolder(&lease) != r.Name {
+		return nil
+	}
+	err := c.Delete(ctx, &lease, client.Preconditions{UID: &lease.UID, ResourceVersion: &lease.ResourceVersion})
+	if apierrors.IsNotFound(err) || apierrors.IsConflict(err) {
+		return nil
+	}
+	return err
+}
+
+func (r *PathFinder) takeOverRegion(ctx context.Context, c client.Client, reader client.Reader, now time.Time) error {
+	regionPath := field.NewPath("spec", "region")
+	lease := coordinationv1.Lease{}
+	key := client.ObjectKey{Namespace: r.Namespace, Name: RegionLeaseName(r.Spec.Region)}
+	if err := reader.Get(ctx, key, &lease); err != nil {
+		return err
+	}
+	holder := LeaseHolder(&lease)
+	if holder == r.Name {
+		return nil
+	}
+
+	pf := PathFinder{}
+	err := reader.Get(ctx, client.ObjectKey{Namespace: r.Namespace, Name: holder}, &pf)
+	if err == nil && strings.EqualFold(pf.Spec.Region, r.Spec.Region) {
+		return r.invalid(field.ErrorList{field.Duplicate(regionPath, r.Spec.Region)})
+	}
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if lease.Spec.AcquireTime != nil && now.Sub(lease.Spec.AcquireTime.Time) < RegionClaimGracePeriod {
+		return r.invalid(field.ErrorList{field.Forbidden(regionPath, fmt.Sprintf("region is being claimed by %s", holder))})
+	}
+
+	claim := NewRegionLease(r, now)
+	lease.Spec = claim.Spec
+	lease.OwnerReferences = nil
+	// Conflicts mean someone else is taking over at the same time
+	return c.Update(ctx, &lease)
+}
@@ -0,0 +1,89 @@
+package v1
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newTestPathFinder(name string, region string) *PathFinder {
+	return &PathFinder{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test"},
+		Spec:       PathFinderSpec{Region: region},
+	}
+}
+
+func TestClaimRegion(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = coordinationv1.AddToScheme(scheme)
+	_ = AddToScheme(scheme)
+	c := fake.NewFakeClientWithScheme(scheme)
+	ctx := context.TODO()
+
+	a := newTestPathFinder("a", "DEFAULT")
+	if err := a.ClaimRegion(ctx, c, c); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.ClaimRegion(ctx, c, c); err != nil {
+		t.Fatalf("Claiming again by the same pathfinder should pass, got %v", err)
+	}
+
+	// a is still being created, region should be protected
+	b := newTestPathFinder("b", "default")
+	if err := b.ClaimRegion(ctx, c, c); !apierrors.IsInvalid(err) {
+		t.Fatalf("Expecting region being claimed, got %v", err)
+	}
+
+	if err := c.Create(ctx, a); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.ClaimRegion(ctx, c, c); !apierrors.IsInvalid(err) {
+		t.Fatalf("Expecting duplicated region, got %v", err)
+	}
+
+	// Claims of pathfinders never created are taken over after grace period
+	stale := NewRegionLease(newTestPathFinder("ghost", "OTHER"), time.Now().Add(-RegionClaimGracePeriod))
+	if err := c.Create(ctx, stale); err != nil {
+		t.Fatal(err)
+	}
+	if err := newTestPathFinder("c", "OTHER").ClaimRegion(ctx, c, c); err != nil {
+		t.Fatalf("Expecting stale claim to be taken over, got %v", err)
+	}
+	lease := coordinationv1.Lease{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: "test", Name: RegionLeaseName("OTHER")}, &lease); err != nil || LeaseHolder(&lease) != "c" {
+		t.Fatalf("Unexpected lease holder %s %v", LeaseHolder(&lease), err)
+	}
+
+	unnamed := newTestPathFinder("", "UNNAMED")
+	unnamed.GenerateName = "pathfinder-"
+	if err := unnamed.ClaimRegion(ctx, c, c); !apierrors.IsInvalid(err) {
+		t.Fatalf("Expecting pathfinder without name rejected, got %v", err)
+	}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: "test", Name: RegionLeaseName("UNNAMED")}, &coordinationv1.Lease{}); !apierrors.IsNotFound(err) {
+		t.Fatalf("Expecting no lease claimed without name, got %v", err)
+	}
+}
+
+func TestClaimRegionOfLegacyPathFinder(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = coordinationv1.AddToScheme(scheme)
+	_ = AddToScheme(scheme)
+	// Pathfinders created before region leases have no lease until adopted by controller
+	legacy := newTestPathFinder("legacy", "DEFAULT")
+	c := fake.NewFakeClientWithScheme(scheme, legacy)
+	ctx := context.TODO()
+
+	if err := newTestPathFinder("b", "DEFAULT").ClaimRegion(ctx, c, c); !apierrors.IsInvalid(err) {
+		t.Fatalf("Expecting duplicated region, got %v", err)
+	}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: "test", Name: RegionLeaseName("DEFAULT")}, &coordinationv1.Lease{}); !apierrors.IsNotFound(err) {
+		t.Fatalf("Expecting lease of rejected claim deleted, got %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package v1
+
+import (
+	"encoding/json"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RegistrationAnnotation is written to annotated services by controller, value is a json Registration
+const RegistrationAnnotation = "XM-PathFinder-Registration"
+
+// Registration reports how a service is registered to pathfinder
+type Registration struct {
+	Region string `json:"region"`
+	// Entries are names of entries of the service published in region
+	Entries []string `json:"entries,omitempty"`
+	// Errors explain why the service is not or partially registered
+	Errors       []string    `json:"errors,omitempty"`
+	LastSyncTime metav1.Time `json:"lastSyncTime"`
+}
+
+// SameAs tells if two registrations are the same regardless of sync time
+func (r *Registration) SameAs(other *Registration) bool {
+	if r.Region != other.Region || len(r.Entries) != len(other.Entries) || len(r.Errors) != len(other.Errors) {
+		return false
+	}
+	for i := range r.Entries {
+		if r.Entries[i] != other.Entries[i] {
+			return false
+		}
+	}
+	for i := range r.Errors {
+		if r.Errors[i] != other.Errors[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseRegistration reads registration from annotations, nil if there is none
+func ParseRegistration(annotations map[string]string) (*Registration, error) {
+	v, ok := annotations[RegistrationAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	reg := Registration{}
+	if err := json.Unmarshal([]byte(v), &reg); err != nil {
+		return nil, err
+	}
+	return &reg, nil
+}
@@ -0,0 +1,28 @@
+package v1
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRegistrationSameAs(t *testing.T) {
+	reg := Registration{Region: "EAST", Entries: []string{"orders/http"}, LastSyncTime: metav1.Now()}
+	other := Registration{Region: "EAST", Entries: []string{"orders/http"}, LastSyncTime: metav1.NewTime(time.Now().Add(-time.Hour))}
+	if !reg.SameAs(&other) {
+		t.Fatal("Registrations differing by sync time should be the same")
+	}
+	other.Errors = []string{"Region EAST has no pathfinder"}
+	if reg.SameAs(&other) {
+		t.Fatal("Registrations with different errors should differ")
+	}
+
+	if reg, err := ParseRegistration(nil); reg != nil || err != nil {
+		t.Fatalf("Expecting no registration, got %v %v", reg, err)
+	}
+	parsed, err := ParseRegistration(map[string]string{RegistrationAnnotation: `{"region":"EAST","entries":["orders/http"]}`})
+	if err != nil || !parsed.SameAs(&reg) {
+		t.Fatalf("Unexpected registration %v %v", parsed, err)
+	}
+}
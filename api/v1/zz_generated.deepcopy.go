@@ -21,15 +21,68 @@ limitations under the License.
 package v1
 
 import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FlapState) DeepCopyInto(out *FlapState) {
+	*out = *in
+	in.WindowStart.DeepCopyInto(&out.WindowStart)
+	if in.SuppressedUntil != nil {
+		in, out := &in.SuppressedUntil, &out.SuppressedUntil
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlapState.
+func (in *FlapState) DeepCopy() *FlapState {
+	if in == nil {
+		return nil
+	}
+	out := new(FlapState)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HealthCheck) DeepCopyInto(out *HealthCheck) {
+	*out = *in
+	out.Interval = in.Interval
+	out.Timeout = in.Timeout
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HealthCheck.
+func (in *HealthCheck) DeepCopy() *HealthCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(HealthCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostTemplateVars) DeepCopyInto(out *HostTemplateVars) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostTemplateVars.
+func (in *HostTemplateVars) DeepCopy() *HostTemplateVars {
+	if in == nil {
+		return nil
+	}
+	out := new(HostTemplateVars)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PathFinder) DeepCopyInto(out *PathFinder) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
@@ -86,6 +139,17 @@ func (in *PathFinderList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PathFinderSpec) DeepCopyInto(out *PathFinderSpec) {
 	*out = *in
+	if in.StaticEntries != nil {
+		in, out := &in.StaticEntries, &out.StaticEntries
+		*out = make([]StaticEntry, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PausedUntil != nil {
+		in, out := &in.PausedUntil, &out.PausedUntil
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PathFinderSpec.
@@ -108,6 +172,32 @@ func (in *PathFinderStatus) DeepCopyInto(out *PathFinderStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Tombstones != nil {
+		in, out := &in.Tombstones, &out.Tombstones
+		*out = make([]Tombstone, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Flaps != nil {
+		in, out := &in.Flaps, &out.Flaps
+		*out = make([]FlapState, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Pending != nil {
+		in, out := &in.Pending, &out.Pending
+		*out = new(PendingChanges)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PathFinderStatus.
@@ -155,10 +245,80 @@ func (in *PayloadKeyValPair) DeepCopy() *PayloadKeyValPair {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PendingChanges) DeepCopyInto(out *PendingChanges) {
+	*out = *in
+	if in.Added != nil {
+		in, out := &in.Added, &out.Added
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Removed != nil {
+		in, out := &in.Removed, &out.Removed
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Changed != nil {
+		in, out := &in.Changed, &out.Changed
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PendingChanges.
+func (in *PendingChanges) DeepCopy() *PendingChanges {
+	if in == nil {
+		return nil
+	}
+	out := new(PendingChanges)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Registration) DeepCopyInto(out *Registration) {
+	*out = *in
+	if in.Entries != nil {
+		in, out := &in.Entries, &out.Entries
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Errors != nil {
+		in, out := &in.Errors, &out.Errors
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.LastSyncTime.DeepCopyInto(&out.LastSyncTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Registration.
+func (in *Registration) DeepCopy() *Registration {
+	if in == nil {
+		return nil
+	}
+	out := new(Registration)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ServiceEntry) DeepCopyInto(out *ServiceEntry) {
 	*out = *in
 	in.Payload.DeepCopyInto(&out.Payload)
+	if in.HealthCheck != nil {
+		in, out := &in.HealthCheck, &out.HealthCheck
+		*out = new(HealthCheck)
+		**out = **in
+	}
+	if in.ReadyEndpoints != nil {
+		in, out := &in.ReadyEndpoints, &out.ReadyEndpoints
+		*out = new(int32)
+		**out = **in
+	}
+	if in.DrainingSince != nil {
+		in, out := &in.DrainingSince, &out.DrainingSince
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceEntry.
@@ -170,3 +330,35 @@ func (in *ServiceEntry) DeepCopy() *ServiceEntry {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StaticEntry) DeepCopyInto(out *StaticEntry) {
+	*out = *in
+	in.Payload.DeepCopyInto(&out.Payload)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StaticEntry.
+func (in *StaticEntry) DeepCopy() *StaticEntry {
+	if in == nil {
+		return nil
+	}
+	out := new(StaticEntry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Tombstone) DeepCopyInto(out *Tombstone) {
+	*out = *in
+	in.RemovedAt.DeepCopyInto(&out.RemovedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Tombstone.
+func (in *Tombstone) DeepCopy() *Tombstone {
+	if in == nil {
+		return nil
+	}
+	out := new(Tombstone)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -2,12 +2,13 @@ package client
 
 import (
 	"context"
-	"fmt"
 	"reflect"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	v1 "github.com/6BD-org/pathfinder/api/v1"
+	"github.com/6BD-org/pathfinder/common"
+	"github.com/6BD-org/pathfinder/consts"
 	"github.com/6BD-org/pathfinder/utils"
 )
 
@@ -31,6 +32,7 @@ type PathFinderV1 interface {
 	GetByRegion(ctx context.Context, region string, pathfinder *v1.PathFinder) error
 	List(ctx context.Context, pathfinderList *v1.PathFinderList, opts PathFinderListOption) error
 	Update(ctx context.Context, pathfinder *v1.PathFinder, opts ...client.UpdateOption) error
+	Namespace() string
 }
 
 // PathFinderV1Impl Not for ploymorphism, but for parameter overview
@@ -73,7 +75,7 @@ func (pfv1 PathFinderV1Impl) GetByRegion(ctx context.Context, region string, pat
 		return err
 	}
 	if len(pfl.Items) == 0 {
-		return fmt.Errorf("Pathfinder with region %s not found in namespace %s", region, pfv1.namespace)
+		return common.NewErr(consts.CODE_REGION_NOT_FOUND, consts.F_ERR_REGION_NOT_FOUND, pfv1.namespace, region)
 	}
 	pfl.Items[0].DeepCopyInto(pathfinder)
 	return nil
@@ -94,7 +96,7 @@ func (pfv1 PathFinderV1Impl) List(ctx context.Context, pathfinderList *v1.PathFi
 	if len(opts.Region) > 0 {
 		filted := utils.Filter(
 			pathfinderList.Items,
-			func(l interface{}) bool { return l.(v1.PathFinder).Spec.Region == opts.Region },
+			func(l interface{}) bool { return v1.SameRegion(l.(v1.PathFinder).Spec.Region, opts.Region) },
 			reflect.TypeOf(v1.PathFinder{}),
 		)
 		pathfinderList.Items = make([]v1.PathFinder, len(filted))
@@ -111,6 +113,11 @@ func (pfv1 PathFinderV1Impl) Update(ctx context.Context, pathfinder *v1.PathFind
 	return pfv1.client.Update(ctx, pathfinder, opts...)
 }
 
+// Namespace of pathfinders accessed by this api
+func (pfv1 PathFinderV1Impl) Namespace() string {
+	return pfv1.namespace
+}
+
 // NewPathFinderV1 Create a new pathfinder v1 api
 func NewPathFinderV1(client client.Client, namespace string) PathFinderV1 {
 	return PathFinderV1Impl{
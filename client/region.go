@@ -0,0 +1,149 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// RegionHeader carries region of the incoming request over http
+	RegionHeader = "X-PathFinder-Region"
+	// RegionMetadataKey carries region of the incoming request over grpc,
+	// grpc metadata keys are always lower case
+	RegionMetadataKey = "x-pathfinder-region"
+)
+
+type regionKey struct{}
+
+type pickedHostKey struct{}
+
+// WithRegion returns a context carrying region, downstream calls
+// using this context are resolved in that region
+func WithRegion(ctx context.Context, region string) context.Context {
+	return context.WithValue(ctx, regionKey{}, region)
+}
+
+// RegionFromContext returns region carried by context
+func RegionFromContext(ctx context.Context) (string, bool) {
+	region, ok := ctx.Value(regionKey{}).(string)
+	return region, ok && len(region) > 0
+}
+
+// WithPickedHost returns a context carrying host picked for a grpc call, so that grpc client
+// interceptors report its result to the host, instead of target of the connection
+func WithPickedHost(ctx context.Context, host string) context.Context {
+	return context.WithValue(ctx, pickedHostKey{}, host)
+}
+
+// pickedHost returns host carried by context, or target of the connection without
+// resolver scheme, which is the picked host if connection is dialed to it
+func pickedHost(ctx context.Context, cc *grpc.ClientConn) string {
+	if host, ok := ctx.Value(pickedHostKey{}).(string); ok && len(host) > 0 {
+		return host
+	}
+	target := cc.Target()
+	if i := strings.Index(target, ":///"); i >= 0 {
+		return target[i+len(":///"):]
+	}
+	return target
+}
+
+// RegionMiddleware reads region header of incoming http requests into request context
+func RegionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if region := r.Header.Get(RegionHeader); len(region) > 0 {
+			r = r.WithContext(WithRegion(r.Context(), region))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// UnaryServerInterceptor reads region metadata of incoming grpc calls into context
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(regionFromIncoming(ctx), req)
+	}
+}
+
+// StreamServerInterceptor reads region metadata of incoming grpc streams into context
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &regionServerStream{ServerStream: ss, ctx: regionFromIncoming(ss.Context())})
+	}
+}
+
+// ResultReporter receives results of calls to hosts, Resolver is a ResultReporter
+type ResultReporter interface {
+	ReportResult(host string, err error)
+}
+
+// UnaryClientInterceptor forwards region in context to outgoing grpc calls,
+// and reports results of calls to the picked host, see WithPickedHost
+func UnaryClientInterceptor(reporters ...ResultReporter) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		err := invoker(regionToOutgoing(ctx), method, req, reply, cc, opts...)
+		reportGRPCResult(ctx, reporters, cc, err)
+		return err
+	}
+}
+
+// StreamClientInterceptor forwards region in context to outgoing grpc streams,
+// and reports failures of opening streams to the picked host, see WithPickedHost
+func StreamClientInterceptor(reporters ...ResultReporter) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		stream, err := streamer(regionToOutgoing(ctx), desc, cc, method, opts...)
+		reportGRPCResult(ctx, reporters, cc, err)
+		return stream, err
+	}
+}
+
+// reportGRPCResult only counts unavailable hosts as failures,
+// other errors are answers of a healthy host
+func reportGRPCResult(ctx context.Context, reporters []ResultReporter, cc *grpc.ClientConn, err error) {
+	if cc == nil {
+		return
+	}
+	if status.Code(err) != codes.Unavailable {
+		err = nil
+	}
+	for _, r := range reporters {
+		r.ReportResult(pickedHost(ctx, cc), err)
+	}
+}
+
+type regionServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *regionServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func regionFromIncoming(ctx context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+	if values := md.Get(RegionMetadataKey); len(values) > 0 && len(values[0]) > 0 {
+		return WithRegion(ctx, values[0])
+	}
+	return ctx
+}
+
+func regionToOutgoing(ctx context.Context) context.Context {
+	region, ok := RegionFromContext(ctx)
+	if !ok {
+		return ctx
+	}
+	if md, ok := metadata.FromOutgoingContext(ctx); ok && len(md.Get(RegionMetadataKey)) > 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, RegionMetadataKey, region)
+}
@@ -0,0 +1,91 @@
+package client
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestRegionPropagationHTTP(t *testing.T) {
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get(RegionHeader)))
+	}))
+	defer downstream.Close()
+
+	httpClient := http.Client{Transport: NewRoundTripper(newTestResolver(strings.TrimPrefix(downstream.URL, "http://")))}
+	upstream := httptest.NewServer(RegionMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, _ := http.NewRequest("GET", "pathfinder:///hello/http/", nil)
+		resp, err := httpClient.Do(req.WithContext(r.Context()))
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		w.Write(body)
+	})))
+	defer upstream.Close()
+
+	req, _ := http.NewRequest("GET", upstream.URL, nil)
+	req.Header.Set(RegionHeader, "DEFAULT")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+	if string(body) != "DEFAULT" {
+		t.Fatalf("Region is not forwarded, got %s %s", resp.Status, body)
+	}
+}
+
+func TestRegionPropagationGRPC(t *testing.T) {
+	incoming := metadata.NewIncomingContext(context.TODO(), metadata.Pairs(RegionMetadataKey, "A"))
+	var forwarded metadata.MD
+	_, err := UnaryServerInterceptor()(incoming, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, UnaryClientInterceptor()(ctx, "/svc/method", nil, nil, nil,
+			func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				forwarded, _ = metadata.FromOutgoingContext(ctx)
+				return nil
+			})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values := forwarded.Get(RegionMetadataKey); len(values) != 1 || values[0] != "A" {
+		t.Fatalf("Region is not forwarded, got %v", forwarded)
+	}
+}
+
+func TestResolverRegion(t *testing.T) {
+	resolver := NewResolver(newTestResolver("a:80").api, WithLocalRegion("LOCAL"))
+	if region, _ := resolver.Region(context.TODO(), ""); region != "LOCAL" {
+		t.Fatalf("Expecting local region, got %s", region)
+	}
+	if region, _ := resolver.Region(WithRegion(context.TODO(), "A"), ""); region != "A" {
+		t.Fatalf("Expecting context region, got %s", region)
+	}
+	if region, _ := resolver.Region(WithRegion(context.TODO(), "A"), "B"); region != "B" {
+		t.Fatalf("Expecting explicit region, got %s", region)
+	}
+}
+
+func TestPickedHost(t *testing.T) {
+	cc, err := grpc.Dial("dns:///hello.test.svc:80", grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+	if host := pickedHost(context.TODO(), cc); host != "hello.test.svc:80" {
+		t.Fatalf("Expecting target without scheme, got %s", host)
+	}
+	if host := pickedHost(WithPickedHost(context.TODO(), "10.0.0.1:80"), cc); host != "10.0.0.1:80" {
+		t.Fatalf("Expecting host in context, got %s", host)
+	}
+}
@@ -0,0 +1,311 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "github.com/6BD-org/pathfinder/api/v1"
+	"github.com/6BD-org/pathfinder/common"
+	"github.com/6BD-org/pathfinder/consts"
+)
+
+// ResolutionSource tells where a resolution comes from
+type ResolutionSource string
+
+const (
+	// SourceAPIServer means entry is read from api server
+	SourceAPIServer ResolutionSource = "APIServer"
+	// SourceSnapshot means entry is read from last known good snapshot
+	SourceSnapshot ResolutionSource = "Snapshot"
+	// SourceConventional means entry is built as name.namespace.svc:port
+	SourceConventional ResolutionSource = "Conventional"
+)
+
+// AddressView chooses between internal and external hosts of entries
+type AddressView string
+
+const (
+	// AddressInternal resolves hosts reachable inside the cluster, which is the default
+	AddressInternal AddressView = "Internal"
+	// AddressExternal resolves hosts reachable from outside the cluster, such as node ports
+	// and load balancers. Pathfinder of the region needs to enable external addresses
+	AddressExternal AddressView = "External"
+)
+
+// Resolution is the result of resolving a service
+type Resolution struct {
+	Entry  v1.ServiceEntry
+	Source ResolutionSource
+	View   AddressView
+	// Stale is true if api server is unreachable and entry
+	// is served from snapshot or built by convention
+	Stale bool
+	// SnapshotTime is the time snapshot was saved, only set when Source is SourceSnapshot
+	SnapshotTime time.Time
+}
+
+// Hosts of resolved entry in view of the resolution
+func (res Resolution) Hosts() []string {
+	if res.View == AddressExternal {
+		return res.Entry.ExternalHosts()
+	}
+	return res.Entry.Hosts()
+}
+
+// ResolverOption configures a resolver
+type ResolverOption func(*Resolver)
+
+// WithSnapshotStore saves last known good pathfinders to store,
+// and serves from it when api server is unreachable
+func WithSnapshotStore(store *SnapshotStore) ResolverOption {
+	return func(r *Resolver) {
+		r.snapshot = store
+	}
+}
+
+// WithLocalRegion sets region of the caller itself, which is used
+// when neither caller nor context specifies a region
+func WithLocalRegion(region string) ResolverOption {
+	return func(r *Resolver) {
+		r.localRegion = region
+	}
+}
+
+// WithOutlierDetection overrides DefaultOutlierDetection of the resolver
+func WithOutlierDetection(outlier OutlierDetection) ResolverOption {
+	return func(r *Resolver) {
+		r.selector.outlier = outlier
+	}
+}
+
+// WithRetryBudget overrides DefaultRetryBudget of the resolver
+func WithRetryBudget(budget RetryBudget) ResolverOption {
+	return func(r *Resolver) {
+		r.selector.budget = budget
+	}
+}
+
+// WithHealthFilter makes resolver refuse entries failing health check of pathfinder controller
+func WithHealthFilter() ResolverOption {
+	return func(r *Resolver) {
+		r.healthFilter = true
+	}
+}
+
+// WithAddressView makes resolver resolve external or internal hosts, AddressInternal by default
+func WithAddressView(view AddressView) ResolverOption {
+	return func(r *Resolver) {
+		r.view = view
+	}
+}
+
+// Resolver resolves registered service names to hosts
+// using pathfinders of one namespace
+type Resolver struct {
+	api          PathFinderV1
+	selector     *PathFinderSelector
+	snapshot     *SnapshotStore
+	localRegion  string
+	healthFilter bool
+	view         AddressView
+
+	mu sync.Mutex
+	// conventional are hosts built from kubernetes services of entries resolved before, by region and service
+	conventional map[string]string
+}
+
+// NewResolver create a resolver on top of a pathfinder v1 api
+func NewResolver(api PathFinderV1, opts ...ResolverOption) *Resolver {
+	r := &Resolver{
+		api:          api,
+		selector:     NewPathFinderSelector(),
+		view:         AddressInternal,
+		conventional: make(map[string]string),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Region returns region to resolve in. If region is empty, region carried
+// by context is used, and then local region of the resolver
+func (r *Resolver) Region(ctx context.Context, region string) (string, error) {
+	if len(region) > 0 {
+		return region, nil
+	}
+	if ctxRegion, ok := RegionFromContext(ctx); ok {
+		return ctxRegion, nil
+	}
+	if len(r.localRegion) > 0 {
+		return r.localRegion, nil
+	}
+	return "", common.NewErr(consts.CODE_REGION_UNSPECIFIED, consts.ERR_REGION_UNSPECIFIED)
+}
+
+// Lookup resolves service in region, see Region for how empty region is handled.
+// If api server is unreachable, snapshot is used, and conventional address
+// name.namespace.svc:port is built as last resort if snapshot has no record of the service,
+// see conventionalHost. Such resolutions are marked as stale
+func (r *Resolver) Lookup(ctx context.Context, region string, service string) (Resolution, error) {
+	region, err := r.Region(ctx, region)
+	if err != nil {
+		return Resolution{}, err
+	}
+	pf := v1.PathFinder{}
+	err = r.api.GetByRegion(ctx, region, &pf)
+	if err == nil {
+		if r.snapshot != nil {
+			// Failing to save snapshot should never fail resolution
+			_ = r.snapshot.Save(&pf)
+		}
+		entry, ok := pf.Status.FindServiceEntry(service)
+		if !ok {
+			if t, removed := pf.Status.FindTombstone(service); removed {
+				return Resolution{}, removedErr(t, service, region)
+			}
+			return Resolution{}, common.NewErr(consts.CODE_SVC_NOT_FOUND, consts.F_ERR_SERVICE_NOT_FOUND, service, region)
+		}
+		r.learnConventionalHost(region, entry)
+		if err := r.checkEntry(entry, region); err != nil {
+			return Resolution{}, err
+		}
+		return Resolution{Entry: entry, Source: SourceAPIServer, View: r.view}, nil
+	}
+	if common.IsPathFinderErr(err) {
+		// Api server answered, so there is nothing to fall back to
+		return Resolution{}, err
+	}
+
+	if r.snapshot != nil {
+		if snapshotPf, savedAt, ok := r.snapshot.Get(region); ok {
+			// Services the controller stopped publishing are not brought back by conventional hosts
+			if entry, ok := snapshotPf.Status.FindServiceEntry(service); ok {
+				if err := r.checkEntry(entry, region); err != nil {
+					return Resolution{}, err
+				}
+				return Resolution{Entry: entry, Source: SourceSnapshot, View: r.view, Stale: true, SnapshotTime: savedAt}, nil
+			}
+			if t, removed := snapshotPf.Status.FindTombstone(service); removed {
+				return Resolution{}, removedErr(t, service, region)
+			}
+		}
+	}
+
+	// Conventional hosts are only reachable inside the cluster
+	if host, ok := r.conventionalHost(region, service); ok && r.view != AddressExternal {
+		return Resolution{
+			Entry:  v1.ServiceEntry{ServiceName: service, ServiceHost: host},
+			Source: SourceConventional,
+			View:   r.view,
+			Stale:  true,
+		}, nil
+	}
+	return Resolution{}, err
+}
+
+func removedErr(t v1.Tombstone, service string, region string) error {
+	return common.NewErr(consts.CODE_SVC_REMOVED, consts.F_ERR_SERVICE_REMOVED,
+		service, region, t.RemovedAt.Format(time.RFC3339), t.Reason)
+}
+
+// checkEntry tells if entry is open for new selections
+func (r *Resolver) checkEntry(entry v1.ServiceEntry, region string) error {
+	if entry.IsDraining() {
+		return common.NewErr(consts.CODE_SVC_DRAINING, consts.F_ERR_SERVICE_DRAINING, entry.ServiceName, region)
+	}
+	if !entry.IsReady() {
+		return common.NewErr(consts.CODE_SVC_NOT_READY, consts.F_ERR_SERVICE_NOT_READY, entry.ServiceName, region)
+	}
+	if r.healthFilter && !entry.IsHealthy() {
+		return common.NewErr(consts.CODE_SVC_UNHEALTHY, consts.F_ERR_SERVICE_UNHEALTHY, entry.ServiceName, region)
+	}
+	return nil
+}
+
+// Resolve returns all hosts registered for service in region
+func (r *Resolver) Resolve(ctx context.Context, region string, service string) ([]string, error) {
+	_, hosts, err := r.resolve(ctx, region, service)
+	return hosts, err
+}
+
+func (r *Resolver) resolve(ctx context.Context, region string, service string) (Resolution, []string, error) {
+	res, err := r.Lookup(ctx, region, service)
+	if err != nil {
+		return res, nil, err
+	}
+	hosts := res.Hosts()
+	if len(hosts) == 0 && res.View == AddressExternal {
+		return res, nil, common.NewErr(consts.CODE_SVC_NO_EXTERNAL, consts.F_ERR_SERVICE_NO_EXTERNAL, service, region)
+	}
+	if len(hosts) == 0 {
+		return res, nil, fmt.Errorf("Service %s in region %s has no host", service, region)
+	}
+	return res, hosts, nil
+}
+
+// Pick resolves service and selects one of its hosts.
+// Hosts in exclude will not be picked
+func (r *Resolver) Pick(ctx context.Context, region string, service string, exclude ...string) (string, error) {
+	host, _, err := r.PickResolution(ctx, region, service, exclude...)
+	return host, err
+}
+
+// PickResolution is Pick returning resolution as well, so that callers
+// can choose transport by scheme and protocol of the entry
+func (r *Resolver) PickResolution(ctx context.Context, region string, service string, exclude ...string) (string, Resolution, error) {
+	res, hosts, err := r.resolve(ctx, region, service)
+	if err != nil {
+		return "", res, err
+	}
+	host, err := r.selector.Select(service, hosts, exclude...)
+	return host, res, err
+}
+
+// ReportResult records result of a call to host, so that failing hosts are ejected
+// from selection. It is called by RoundTripper and grpc client interceptors automatically
+func (r *Resolver) ReportResult(host string, err error) {
+	r.selector.ReportResult(host, err)
+}
+
+// learnConventionalHost remembers conventional host of entry, so that it can be
+// built even if the entry can't be read later
+func (r *Resolver) learnConventionalHost(region string, entry v1.ServiceEntry) {
+	host, ok := entry.ConventionalHost(r.api.Namespace())
+	if !ok {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.conventional[v1.NormalizeRegion(region)+"|"+entry.ServiceName] = host
+}
+
+// conventionalHost returns host built from kubernetes service of the entry if it was resolved
+// before. Otherwise, service like name/8080 is taken as kubernetes service name and port number,
+// named ports like name/http can't be built without knowing the service
+func (r *Resolver) conventionalHost(region string, service string) (string, bool) {
+	r.mu.Lock()
+	host, ok := r.conventional[v1.NormalizeRegion(region)+"|"+service]
+	r.mu.Unlock()
+	if ok {
+		return host, true
+	}
+	return numericConventionalHost(service, r.api.Namespace())
+}
+
+// numericConventionalHost builds name.namespace.svc:port from service like name/port,
+// which is only possible when name is the kubernetes service name and port is a number
+func numericConventionalHost(service string, namespace string) (string, bool) {
+	segs := strings.SplitN(service, "/", 2)
+	if len(segs) != 2 || len(namespace) == 0 {
+		return "", false
+	}
+	if _, err := strconv.ParseUint(segs[1], 10, 16); err != nil {
+		return "", false
+	}
+	return fmt.Sprintf("%s.%s.svc:%s", segs[0], namespace, segs[1]), true
+}
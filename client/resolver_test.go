@@ -0,0 +1,64 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	v1 "github.com/6BD-org/pathfinder/api/v1"
+	"github.com/6BD-org/pathfinder/common"
+	"github.com/6BD-org/pathfinder/consts"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestResolverAddressView(t *testing.T) {
+	pf := &v1.PathFinder{
+		ObjectMeta: metav1.ObjectMeta{Name: "pf", Namespace: testNs},
+		Spec:       v1.PathFinderSpec{Region: "DEFAULT", ExternalAddresses: true},
+		Status: v1.PathFinderStatus{
+			ServiceEntries: []v1.ServiceEntry{
+				{ServiceName: "hello/http", ServiceHost: "hello.test.svc:80", ExternalHost: "203.0.113.1:80"},
+				{ServiceName: "internal/http", ServiceHost: "internal.test.svc:80"},
+			},
+		},
+	}
+	api := NewPathFinderV1(fake.NewFakeClientWithScheme(scheme, pf), testNs)
+
+	hosts, err := NewResolver(api).Resolve(context.TODO(), "DEFAULT", "hello/http")
+	if err != nil || len(hosts) != 1 || hosts[0] != "hello.test.svc:80" {
+		t.Fatalf("Unexpected internal hosts %v %v", hosts, err)
+	}
+
+	external := NewResolver(api, WithAddressView(AddressExternal))
+	hosts, err = external.Resolve(context.TODO(), "DEFAULT", "hello/http")
+	if err != nil || len(hosts) != 1 || hosts[0] != "203.0.113.1:80" {
+		t.Fatalf("Unexpected external hosts %v %v", hosts, err)
+	}
+	if _, err := external.Resolve(context.TODO(), "DEFAULT", "internal/http"); !common.IsErrCode(err, consts.CODE_SVC_NO_EXTERNAL) {
+		t.Fatalf("Expecting no external address error, got %v", err)
+	}
+}
+
+func TestResolverConventionalHost(t *testing.T) {
+	pf := &v1.PathFinder{
+		ObjectMeta: metav1.ObjectMeta{Name: "pf", Namespace: testNs},
+		Spec:       v1.PathFinderSpec{Region: "DEFAULT"},
+		Status: v1.PathFinderStatus{
+			ServiceEntries: []v1.ServiceEntry{
+				{ServiceName: "hello/http", ServiceHost: "10.0.0.1:80", KubeServiceName: "hello-v2", Port: 80},
+			},
+		},
+	}
+	api := &flakyAPI{PathFinderV1: NewPathFinderV1(fake.NewFakeClientWithScheme(scheme, pf), testNs)}
+	resolver := NewResolver(api)
+
+	if _, err := resolver.Lookup(context.TODO(), "DEFAULT", "hello/http"); err != nil {
+		t.Fatal(err)
+	}
+	// Named ports are built from kubernetes service of the entry resolved before
+	api.down = true
+	res, err := resolver.Lookup(context.TODO(), "default", "hello/http")
+	if err != nil || res.Source != SourceConventional || res.Entry.ServiceHost != "hello-v2.test.svc:80" {
+		t.Fatalf("Unexpected resolution %v %v", res, err)
+	}
+}
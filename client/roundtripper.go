@@ -0,0 +1,205 @@
+package client
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	v1 "github.com/6BD-org/pathfinder/api/v1"
+	"github.com/pkg/errors"
+)
+
+const (
+	// PathFinderScheme is the url scheme handled by RoundTripper
+	// pathfinder://region/service/port/path
+	PathFinderScheme = "pathfinder"
+	// UnnamedPort is used as port segment for services registered with unnamed port
+	UnnamedPort = "-"
+)
+
+// RoundTripper rewrites pathfinder:// urls to resolved hosts and
+// sends requests with Next. Requests of other schemes are sent as they are.
+// Urls without region, like pathfinder:///service/port/path, are resolved
+// in region of request context, which is also forwarded in RegionHeader
+type RoundTripper struct {
+	Resolver *Resolver
+	// Next sends rewritten requests, http.DefaultTransport is used if nil
+	Next http.RoundTripper
+	// Scheme of rewritten url, overrides scheme of entries. If empty,
+	// entries of https scheme are requested with https, others with http
+	Scheme string
+	// Retries is the number of other hosts to try when connection fails
+	Retries int
+}
+
+// NewRoundTripper create a round tripper resolving urls with resolver
+func NewRoundTripper(resolver *Resolver) *RoundTripper {
+	return &RoundTripper{
+		Resolver: resolver,
+		Next:     http.DefaultTransport,
+	}
+}
+
+// RoundTrip implements http.RoundTripper
+func (rt *RoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := rt.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if req.URL.Scheme != PathFinderScheme {
+		return next.RoundTrip(forwardRegion(req))
+	}
+
+	target, err := ParseURL(req.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	retries := rt.Retries
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		// Body can not be rewound, so request is sent only once
+		retries = 0
+	}
+
+	selector := rt.Resolver.selector
+	selector.RecordRequest(target.EntryName())
+	tried := make([]string, 0)
+	for {
+		host, res, err := rt.Resolver.PickResolution(req.Context(), target.Region, target.EntryName(), tried...)
+		if err != nil {
+			if len(tried) > 0 {
+				return nil, errors.Wrapf(err, "All hosts of %s failed", target.EntryName())
+			}
+			return nil, err
+		}
+		tried = append(tried, host)
+
+		scheme, err := rt.scheme(res.Entry)
+		if err != nil {
+			return nil, err
+		}
+		outReq, err := rt.rewrite(req, target, scheme, host)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := next.RoundTrip(outReq)
+		rt.Resolver.ReportResult(host, resultErr(resp, err))
+		if err == nil || !isConnectionFailure(err) || len(tried) > retries {
+			return resp, err
+		}
+		if !selector.AllowRetry(target.EntryName()) {
+			return resp, errors.Wrapf(err, "Retry budget of %s exhausted", target.EntryName())
+		}
+	}
+}
+
+// resultErr tells if a call to host failed, gateway errors count as failures
+// because they mean host is not able to serve
+func resultErr(resp *http.Response, err error) error {
+	if err != nil {
+		return err
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return fmt.Errorf("Host responded %s", resp.Status)
+	}
+	return nil
+}
+
+// scheme chooses url scheme for entry, entries not over tcp can't be requested
+func (rt *RoundTripper) scheme(entry v1.ServiceEntry) (string, error) {
+	if len(entry.Protocol) > 0 && entry.Protocol != "TCP" {
+		return "", fmt.Errorf("Service %s is not reachable over http, protocol is %s", entry.ServiceName, entry.Protocol)
+	}
+	if len(rt.Scheme) > 0 {
+		return rt.Scheme, nil
+	}
+	if entry.Scheme == "https" {
+		return "https", nil
+	}
+	return "http", nil
+}
+
+func (rt *RoundTripper) rewrite(req *http.Request, target Target, scheme string, host string) (*http.Request, error) {
+	outReq := req.Clone(req.Context())
+	outReq.URL.Scheme = scheme
+	outReq.URL.Host = host
+	outReq.URL.Path = target.Path
+	outReq.URL.RawPath = ""
+	outReq.Host = host
+	if region, ok := RegionFromContext(req.Context()); ok && len(outReq.Header.Get(RegionHeader)) == 0 {
+		outReq.Header.Set(RegionHeader, region)
+	}
+
+	// Body can only be read once, so it must be rewound for retries
+	if req.Body != nil && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		outReq.Body = body
+	}
+	return outReq, nil
+}
+
+// Target is a parsed pathfinder url
+type Target struct {
+	Region  string
+	Service string
+	Port    string
+	Path    string
+}
+
+// EntryName is the name of service entry registered in pathfinder
+func (t Target) EntryName() string {
+	if len(t.Port) == 0 || t.Port == UnnamedPort {
+		return t.Service
+	}
+	return fmt.Sprintf("%s/%s", t.Service, t.Port)
+}
+
+// ParseURL parses url like pathfinder://region/service/port/path,
+// region is empty for urls like pathfinder:///service/port/path
+func ParseURL(u *url.URL) (Target, error) {
+	if u.Scheme != PathFinderScheme {
+		return Target{}, fmt.Errorf("Invalid pathfinder url %s", u)
+	}
+	segs := strings.SplitN(strings.TrimPrefix(u.Path, "/"), "/", 3)
+	if len(segs) < 2 || len(segs[0]) == 0 || len(segs[1]) == 0 {
+		return Target{}, fmt.Errorf("Invalid pathfinder url %s, expecting pathfinder://region/service/port/path", u)
+	}
+	target := Target{
+		Region:  u.Host,
+		Service: segs[0],
+		Port:    segs[1],
+		Path:    "/",
+	}
+	if len(segs) == 3 {
+		target.Path = "/" + segs[2]
+	}
+	return target, nil
+}
+
+// forwardRegion sets region header from context if it is not set yet
+func forwardRegion(req *http.Request) *http.Request {
+	region, ok := RegionFromContext(req.Context())
+	if !ok || len(req.Header.Get(RegionHeader)) > 0 {
+		return req
+	}
+	// RoundTripper should not modify the original request
+	outReq := req.Clone(req.Context())
+	outReq.Header.Set(RegionHeader, region)
+	return outReq
+}
+
+// isConnectionFailure tells if request failed before connection established,
+// so that it is safe to retry on another host
+func isConnectionFailure(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return opErr.Op == "dial"
+	}
+	return false
+}
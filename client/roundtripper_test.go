@@ -0,0 +1,112 @@
+package client
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	v1 "github.com/6BD-org/pathfinder/api/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newTestResolver(hosts string) *Resolver {
+	pf := &v1.PathFinder{
+		ObjectMeta: metav1.ObjectMeta{Name: "pf", Namespace: testNs},
+		Spec:       v1.PathFinderSpec{Region: "DEFAULT"},
+		Status: v1.PathFinderStatus{
+			ServiceEntries: []v1.ServiceEntry{
+				{ServiceName: "hello/http", ServiceHost: hosts},
+			},
+		},
+	}
+	cl := fake.NewFakeClientWithScheme(scheme, pf)
+	return NewResolver(NewPathFinderV1(cl, testNs))
+}
+
+// unusedAddr returns an address nobody listens on
+func unusedAddr(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	return addr
+}
+
+func TestParseURL(t *testing.T) {
+	req, _ := http.NewRequest("GET", "pathfinder://DEFAULT/hello/-/a/b?c=d", nil)
+	target, err := ParseURL(req.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target.Region != "DEFAULT" || target.EntryName() != "hello" || target.Path != "/a/b" {
+		t.Fatalf("Unexpected target %v", target)
+	}
+
+	req, _ = http.NewRequest("GET", "pathfinder://DEFAULT/hello", nil)
+	if _, err := ParseURL(req.URL); err == nil {
+		t.Fatal("Expecting error for url without port")
+	}
+}
+
+func TestRoundTripperRetry(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path + "?" + r.URL.RawQuery))
+	}))
+	defer server.Close()
+
+	dead := unusedAddr(t)
+	live := strings.TrimPrefix(server.URL, "http://")
+	rt := NewRoundTripper(newTestResolver(dead + "," + live))
+	rt.Retries = 1
+	httpClient := http.Client{Transport: rt}
+
+	for i := 0; i < 2; i++ {
+		resp, err := httpClient.Get("pathfinder://DEFAULT/hello/http/api/ping?x=1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != "/api/ping?x=1" {
+			t.Fatalf("Unexpected body %s", body)
+		}
+	}
+
+	rt.Retries = 0
+	failed := false
+	for i := 0; i < 2; i++ {
+		if _, err := httpClient.Get("pathfinder://DEFAULT/hello/http/"); err != nil {
+			failed = true
+		}
+	}
+	if !failed {
+		t.Fatal("Expecting failure on dead host without retries")
+	}
+}
+
+func TestRoundTripperScheme(t *testing.T) {
+	rt := NewRoundTripper(nil)
+	cases := map[string]v1.ServiceEntry{
+		"http":  {Protocol: "TCP", Scheme: "h2c"},
+		"https": {Protocol: "TCP", Scheme: "https"},
+	}
+	for expected, entry := range cases {
+		if scheme, err := rt.scheme(entry); err != nil || scheme != expected {
+			t.Fatalf("Expecting %s, got %s %v", expected, scheme, err)
+		}
+	}
+	if _, err := rt.scheme(v1.ServiceEntry{Protocol: "UDP"}); err == nil {
+		t.Fatal("Expecting error for udp entry")
+	}
+
+	rt.Scheme = "https"
+	if scheme, _ := rt.scheme(v1.ServiceEntry{Scheme: "http"}); scheme != "https" {
+		t.Fatalf("Scheme of round tripper should take precedence, got %s", scheme)
+	}
+}
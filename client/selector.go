@@ -1,4 +1,195 @@
 package client
 
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// OutlierDetection ejects hosts failing consecutively. An ejected host is
+// re-admitted after ejection time, which doubles each time the host is ejected again
+type OutlierDetection struct {
+	// ConsecutiveFailures before a host is ejected, 0 disables ejection
+	ConsecutiveFailures int
+	BaseEjectionTime    time.Duration
+	MaxEjectionTime     time.Duration
+}
+
+// RetryBudget limits retries of a service to a ratio of its requests in a time window,
+// so that retries never multiply load on a failing service
+type RetryBudget struct {
+	// Ratio of retries to requests
+	Ratio float64
+	// MinRetries is always allowed in a window, regardless of ratio
+	MinRetries int
+	Window     time.Duration
+}
+
+// DefaultOutlierDetection ejects a host for 30s after 5 consecutive failures
+var DefaultOutlierDetection = OutlierDetection{
+	ConsecutiveFailures: 5,
+	BaseEjectionTime:    30 * time.Second,
+	MaxEjectionTime:     5 * time.Minute,
+}
+
+// DefaultRetryBudget allows retrying 20% of requests in 10s, with at least 3 retries
+var DefaultRetryBudget = RetryBudget{
+	Ratio:      0.2,
+	MinRetries: 3,
+	Window:     10 * time.Second,
+}
+
+type hostState struct {
+	failures     int
+	ejections    int
+	ejectedUntil time.Time
+}
+
+type budgetState struct {
+	windowStart time.Time
+	requests    int
+	retries     int
+}
+
+// PathFinderSelector picks one host among hosts of a service entry.
+// Hosts of a same service are picked in round robin manner,
+// skipping hosts ejected by outlier detection
 type PathFinderSelector struct {
+	mu       sync.Mutex
+	counters map[string]uint64
+	hosts    map[string]*hostState
+	budgets  map[string]*budgetState
+
+	outlier OutlierDetection
+	budget  RetryBudget
+	now     func() time.Time
+}
+
+// NewPathFinderSelector create a new selector with default outlier detection and retry budget
+func NewPathFinderSelector() *PathFinderSelector {
+	return &PathFinderSelector{
+		counters: make(map[string]uint64),
+		hosts:    make(map[string]*hostState),
+		budgets:  make(map[string]*budgetState),
+		outlier:  DefaultOutlierDetection,
+		budget:   DefaultRetryBudget,
+		now:      time.Now,
+	}
+}
+
+// Select picks a host for service. Hosts in exclude are skipped,
+// this is useful when retrying on another host.
+// If all hosts are ejected, ejection is ignored so that service stays reachable
+func (s *PathFinderSelector) Select(service string, hosts []string, exclude ...string) (string, error) {
+	candidates := make([]string, 0, len(hosts))
+	for _, h := range hosts {
+		if !contains(exclude, h) {
+			candidates = append(candidates, h)
+		}
+	}
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("No host available for service %s", service)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	admitted := make([]string, 0, len(candidates))
+	for _, h := range candidates {
+		if state, ok := s.hosts[h]; !ok || !now.Before(state.ejectedUntil) {
+			admitted = append(admitted, h)
+		}
+	}
+	if len(admitted) > 0 {
+		candidates = admitted
+	}
+
+	n := s.counters[service]
+	s.counters[service] = n + 1
+	return candidates[n%uint64(len(candidates))], nil
+}
+
+// ReportResult records result of a call to host, nil err means success.
+// Failures are not tracked if ejection is disabled
+func (s *PathFinderSelector) ReportResult(host string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.hosts[host]
+	if err == nil {
+		if !ok {
+			return
+		}
+		state.failures = 0
+		// Ejection time stops doubling once host stays healthy long enough
+		if s.now().After(state.ejectedUntil.Add(s.outlier.MaxEjectionTime)) {
+			delete(s.hosts, host)
+		}
+		return
+	}
+	if s.outlier.ConsecutiveFailures <= 0 {
+		return
+	}
+	if !ok {
+		state = &hostState{}
+		s.hosts[host] = state
+	}
+	state.failures++
+	if state.failures < s.outlier.ConsecutiveFailures {
+		return
+	}
+
+	ejection := s.outlier.BaseEjectionTime << uint(state.ejections)
+	if ejection > s.outlier.MaxEjectionTime || ejection <= 0 {
+		ejection = s.outlier.MaxEjectionTime
+	}
+	state.ejections++
+	state.failures = 0
+	state.ejectedUntil = s.now().Add(ejection)
+}
+
+// Ejected tells if host is ejected at the moment
+func (s *PathFinderSelector) Ejected(host string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.hosts[host]
+	return ok && s.now().Before(state.ejectedUntil)
+}
+
+// RecordRequest counts a request to service against retry budget
+func (s *PathFinderSelector) RecordRequest(service string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.budgetOf(service).requests++
+}
+
+// AllowRetry tells if a retry of service is in budget, and counts it if so
+func (s *PathFinderSelector) AllowRetry(service string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b := s.budgetOf(service)
+	if b.retries >= s.budget.MinRetries && float64(b.retries+1) > s.budget.Ratio*float64(b.requests) {
+		return false
+	}
+	b.retries++
+	return true
+}
+
+// budgetOf returns budget of current window, caller must hold the lock
+func (s *PathFinderSelector) budgetOf(service string) *budgetState {
+	now := s.now()
+	b, ok := s.budgets[service]
+	if !ok || now.Sub(b.windowStart) >= s.budget.Window {
+		b = &budgetState{windowStart: now}
+		s.budgets[service] = b
+	}
+	return b
+}
+
+func contains(l []string, s string) bool {
+	for _, e := range l {
+		if e == s {
+			return true
+		}
+	}
+	return false
 }
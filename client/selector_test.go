@@ -0,0 +1,73 @@
+package client
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSelectorOutlierDetection(t *testing.T) {
+	now := time.Now()
+	s := NewPathFinderSelector()
+	s.now = func() time.Time { return now }
+	hosts := []string{"a:80", "b:80"}
+	failure := errors.New("connection refused")
+
+	for i := 0; i < DefaultOutlierDetection.ConsecutiveFailures; i++ {
+		s.ReportResult("a:80", failure)
+	}
+	if !s.Ejected("a:80") {
+		t.Fatal("Expecting a:80 to be ejected")
+	}
+	for i := 0; i < 4; i++ {
+		if h, _ := s.Select("svc", hosts); h != "b:80" {
+			t.Fatalf("Ejected host %s is selected", h)
+		}
+	}
+
+	// All hosts ejected, ejection is ignored
+	for i := 0; i < DefaultOutlierDetection.ConsecutiveFailures; i++ {
+		s.ReportResult("b:80", failure)
+	}
+	if h, err := s.Select("svc", hosts); err != nil || len(h) == 0 {
+		t.Fatalf("Expecting a host when all hosts are ejected, got %s %v", h, err)
+	}
+
+	// Re-admitted after base ejection time, ejected twice as long next time
+	now = now.Add(DefaultOutlierDetection.BaseEjectionTime)
+	if s.Ejected("a:80") {
+		t.Fatal("Expecting a:80 to be re-admitted")
+	}
+	for i := 0; i < DefaultOutlierDetection.ConsecutiveFailures; i++ {
+		s.ReportResult("a:80", failure)
+	}
+	now = now.Add(DefaultOutlierDetection.BaseEjectionTime)
+	if !s.Ejected("a:80") {
+		t.Fatal("Expecting ejection time of a:80 to be doubled")
+	}
+}
+
+func TestSelectorEjectionDisabled(t *testing.T) {
+	s := NewPathFinderSelector()
+	s.outlier.ConsecutiveFailures = 0
+	s.ReportResult("a:80", errors.New("connection refused"))
+	if len(s.hosts) != 0 {
+		t.Fatalf("Hosts should not be tracked when ejection is disabled, got %v", s.hosts)
+	}
+}
+
+func TestSelectorRetryBudget(t *testing.T) {
+	s := NewPathFinderSelector()
+	for i := 0; i < 10; i++ {
+		s.RecordRequest("svc")
+	}
+	allowed := 0
+	for i := 0; i < 10; i++ {
+		if s.AllowRetry("svc") {
+			allowed++
+		}
+	}
+	if allowed != DefaultRetryBudget.MinRetries {
+		t.Fatalf("Expecting %d retries allowed, got %d", DefaultRetryBudget.MinRetries, allowed)
+	}
+}
@@ -0,0 +1,110 @@
+package client
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	v1 "github.com/6BD-org/pathfinder/api/v1"
+)
+
+// Snapshot is the last known good pathfinders, saved by region
+type Snapshot struct {
+	// SavedAt is the time any region was saved last, it is used for regions without RegionSavedAt
+	SavedAt time.Time                `json:"savedAt"`
+	Regions map[string]v1.PathFinder `json:"regions"`
+	// RegionSavedAt is the time each region was saved
+	RegionSavedAt map[string]time.Time `json:"regionSavedAt,omitempty"`
+}
+
+// SnapshotStore keeps a snapshot in memory and persists it to a local file
+type SnapshotStore struct {
+	mu       sync.RWMutex
+	path     string
+	snapshot Snapshot
+}
+
+// NewSnapshotStore create a store backed by file at path.
+// Existing snapshot in that file is loaded so that it can be used at startup
+func NewSnapshotStore(path string) (*SnapshotStore, error) {
+	s := &SnapshotStore{
+		path: path,
+		snapshot: Snapshot{
+			Regions:       make(map[string]v1.PathFinder),
+			RegionSavedAt: make(map[string]time.Time),
+		},
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.snapshot); err != nil {
+		return nil, err
+	}
+	if s.snapshot.Regions == nil {
+		s.snapshot.Regions = make(map[string]v1.PathFinder)
+	}
+	// Snapshots written before regions were timed separately
+	if s.snapshot.RegionSavedAt == nil {
+		s.snapshot.RegionSavedAt = make(map[string]time.Time)
+	}
+	return s, nil
+}
+
+// Get returns pathfinder of region from snapshot and the time it was saved
+func (s *SnapshotStore) Get(region string) (v1.PathFinder, time.Time, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	region = v1.NormalizeRegion(region)
+	pf, ok := s.snapshot.Regions[region]
+	savedAt, timed := s.snapshot.RegionSavedAt[region]
+	if !timed {
+		savedAt = s.snapshot.SavedAt
+	}
+	return pf, savedAt, ok
+}
+
+// Save records pathfinder of a region and writes snapshot to file.
+// Nothing is written if the pathfinder is not changed since last save
+func (s *SnapshotStore) Save(pf *v1.PathFinder) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	region := v1.NormalizeRegion(pf.Spec.Region)
+	old, ok := s.snapshot.Regions[region]
+	if ok && len(old.ResourceVersion) > 0 && old.ResourceVersion == pf.ResourceVersion {
+		return nil
+	}
+	now := time.Now()
+	s.snapshot.Regions[region] = *pf.DeepCopy()
+	s.snapshot.RegionSavedAt[region] = now
+	s.snapshot.SavedAt = now
+	return s.write()
+}
+
+// write replaces snapshot file atomically so that a crash never leaves a broken file
+func (s *SnapshotStore) write() error {
+	data, err := json.Marshal(s.snapshot)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
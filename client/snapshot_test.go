@@ -0,0 +1,135 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	v1 "github.com/6BD-org/pathfinder/api/v1"
+	"github.com/6BD-org/pathfinder/common"
+	"github.com/6BD-org/pathfinder/consts"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// flakyAPI simulates an unreachable api server
+type flakyAPI struct {
+	PathFinderV1
+	down bool
+}
+
+func (api *flakyAPI) GetByRegion(ctx context.Context, region string, pathfinder *v1.PathFinder) error {
+	if api.down {
+		return errors.New("connection refused")
+	}
+	return api.PathFinderV1.GetByRegion(ctx, region, pathfinder)
+}
+
+func TestSnapshotFallback(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pathfinder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "snapshot.json")
+
+	store, err := NewSnapshotStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := &flakyAPI{PathFinderV1: newTestResolver("a:80,b:80").api}
+	resolver := NewResolver(api, WithSnapshotStore(store))
+
+	res, err := resolver.Lookup(context.TODO(), "DEFAULT", "hello/http")
+	if err != nil || res.Stale || res.Source != SourceAPIServer {
+		t.Fatalf("Unexpected resolution %v %v", res, err)
+	}
+
+	// A new store loads snapshot written by previous one, like after a restart
+	api.down = true
+	store, err = NewSnapshotStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolver = NewResolver(api, WithSnapshotStore(store))
+	res, err = resolver.Lookup(context.TODO(), "DEFAULT", "hello/http")
+	if err != nil || !res.Stale || res.Source != SourceSnapshot || len(res.Hosts()) != 2 {
+		t.Fatalf("Unexpected resolution %v %v", res, err)
+	}
+
+	res, err = resolver.Lookup(context.TODO(), "DEFAULT", "hello/8080")
+	if err != nil || res.Source != SourceConventional || res.Entry.ServiceHost != "hello.test.svc:8080" {
+		t.Fatalf("Unexpected resolution %v %v", res, err)
+	}
+
+	if _, err = resolver.Lookup(context.TODO(), "DEFAULT", "hello/grpc"); err == nil {
+		t.Fatal("Expecting error when nothing can be resolved")
+	}
+}
+
+func TestSnapshotTimeByRegion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pathfinder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := NewSnapshotStore(filepath.Join(dir, "snapshot.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	east := &v1.PathFinder{Spec: v1.PathFinderSpec{Region: "EAST"}}
+	west := &v1.PathFinder{Spec: v1.PathFinderSpec{Region: "WEST"}}
+	if err := store.Save(east); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if err := store.Save(west); err != nil {
+		t.Fatal(err)
+	}
+	_, eastAt, _ := store.Get("east")
+	_, westAt, _ := store.Get("WEST")
+	if !eastAt.Before(westAt) {
+		t.Fatalf("Expecting regions to keep their own save time, got %v %v", eastAt, westAt)
+	}
+}
+
+func TestSnapshotDrainingEntry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pathfinder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store, err := NewSnapshotStore(filepath.Join(dir, "snapshot.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pf := &v1.PathFinder{
+		ObjectMeta: metav1.ObjectMeta{Name: "pf", Namespace: testNs},
+		Spec:       v1.PathFinderSpec{Region: "DEFAULT"},
+		Status: v1.PathFinderStatus{
+			ServiceEntries: []v1.ServiceEntry{
+				{ServiceName: "hello/http", ServiceHost: "10.0.0.1:80", KubeServiceName: "hello", Port: 80, State: v1.EntryDraining},
+			},
+			Tombstones: []v1.Tombstone{{ServiceName: "bye/http", RemovedAt: metav1.Now(), Reason: "deleted"}},
+		},
+	}
+	api := &flakyAPI{PathFinderV1: NewPathFinderV1(fake.NewFakeClientWithScheme(scheme, pf), testNs)}
+	resolver := NewResolver(api, WithSnapshotStore(store))
+	if _, err := resolver.Lookup(context.TODO(), "DEFAULT", "hello/http"); !common.IsErrCode(err, consts.CODE_SVC_DRAINING) {
+		t.Fatalf("Expecting draining, got %v", err)
+	}
+
+	// Snapshot answers as api server did, instead of falling back to conventional hosts
+	api.down = true
+	if res, err := resolver.Lookup(context.TODO(), "DEFAULT", "hello/http"); !common.IsErrCode(err, consts.CODE_SVC_DRAINING) {
+		t.Fatalf("Expecting draining from snapshot, got %v %v", res, err)
+	}
+	if res, err := resolver.Lookup(context.TODO(), "DEFAULT", "bye/http"); !common.IsErrCode(err, consts.CODE_SVC_REMOVED) {
+		t.Fatalf("Expecting removed from snapshot, got %v %v", res, err)
+	}
+}
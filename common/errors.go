@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/6BD-org/pathfinder/consts"
+	"github.com/pkg/errors"
 )
 
 type PathFinderError struct {
@@ -23,3 +24,18 @@ func NewErr(errCode consts.ErrCode, msg string, args ...interface{}) PathFinderE
 		Msg:     fmt.Sprintf(msg, args...),
 	}
 }
+
+// IsErrCode tells if err is a PathFinderError with errCode
+func IsErrCode(err error, errCode consts.ErrCode) bool {
+	var pfe PathFinderError
+	if errors.As(err, &pfe) {
+		return pfe.ErrCode == errCode
+	}
+	return false
+}
+
+// IsPathFinderErr tells if err is a PathFinderError
+func IsPathFinderErr(err error) bool {
+	var pfe PathFinderError
+	return errors.As(err, &pfe)
+}
@@ -26,7 +26,7 @@ func GetPathFinderRegion(c client.Client, namespace string, region string) (*v1.
 
 	filted := utils.Filter(
 		pl.Items,
-		func(pf interface{}) bool { return pf.(v1.PathFinder).Spec.Region == region },
+		func(pf interface{}) bool { return v1.SameRegion(pf.(v1.PathFinder).Spec.Region, region) },
 		reflect.TypeOf(v1.PathFinder{}),
 	)
 
@@ -37,7 +37,7 @@ func GetPathFinderRegion(c client.Client, namespace string, region string) (*v1.
 		return nil, NewErr(consts.CODE_REGION_NOT_FOUND, consts.F_ERR_REGION_NOT_FOUND, namespace, region)
 	}
 	for _, pf := range pl.Items {
-		if pf.Spec.Region == region {
+		if v1.SameRegion(pf.Spec.Region, region) {
 			return &pf, nil
 		}
 	}
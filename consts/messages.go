@@ -7,19 +7,51 @@ const (
 	ERR_REGION_UNSPECIFIED       = "Region Unspecified"
 	ERR_SERVICE_NAME_UNSPECIFIED = "Service Name Unspecified"
 	ERR_WEBHOOK_INIT_FAIL        = "Unable to initialize webhook"
+	ERR_HEALTH_UPDATE_FAIL       = "Fail to update health of service entry"
+	ERR_REBUILD_REGION           = "Fail to rebuild pathfinder region"
+	ERR_REGION_LEASE             = "Fail to ensure region lease of pathfinder"
+	ERR_RELEASE_REGION_LEASE     = "Fail to release stale region lease"
+	ERR_FINALIZE                 = "Fail to finalize pathfinder"
+	ERR_PROVISION                = "Fail to auto provision pathfinder"
 
 	INFO_UPDATINGPATHFINDER = "Updating PathFinder"
 	INFO_START_CLEANUP      = "Starting cleanup"
+	INFO_HEALTH_CHANGED     = "Health of service entry changed"
+	INFO_ENTRY_NOT_READY    = "Service entry excluded for not enough ready endpoints"
+	INFO_ENTRY_FLAPPING     = "Service entry is flapping, holding its published state"
+	INFO_REGION_PAUSED      = "Region is paused, changes of service entries are pending"
+	INFO_REGION_PROVISIONED = "Region is auto provisioned"
 
-	WARN_REGION_UNSPECIFIED   = "Region unspecified. Using default"
-	WARN_NO_SERVICE_IN_REGION = "No service found in region"
-	WARN_REGION_NOT_FOUND     = "Region not found"
-	WARN_REGION_INCONSISTENT  = "In consistent region"
+	WARN_REGION_UNSPECIFIED      = "Region unspecified. Using default"
+	WARN_NO_SERVICE_IN_REGION    = "No service found in region"
+	WARN_REGION_NOT_FOUND        = "Region not found"
+	WARN_REGION_INCONSISTENT     = "In consistent region"
+	WARN_INVALID_HEALTH_CHECK    = "Invalid health check annotations, health check disabled"
+	WARN_INVALID_MIN_READY       = "Invalid minimum ready endpoints annotation, readiness gating disabled"
+	WARN_PANIC_THRESHOLD         = "Too many entries dropped in one reconcile, keeping old entries"
+	WARN_NAME_COLLISION          = "Services registered entries of the same name, collision policy applied"
+	WARN_PROVISION_LIMIT         = "Region not auto provisioned, limit of auto provisioned regions reached"
+	WARN_REHOME_REGION_NOT_FOUND = "Region to rehome services not found, services are orphaned"
+	WARN_STATIC_ENTRY_SHADOWED   = "Static entry ignored, a service is registered with the same name"
 )
 
 const (
-	F_ERR_REGION_NOT_FOUND  = "Region not found %s %s"
-	F_ERR_DUPLICATED_REGION = "Duplicated region found %s %s"
+	F_ERR_REGION_NOT_FOUND    = "Region not found %s %s"
+	F_ERR_DUPLICATED_REGION   = "Duplicated region found %s %s"
+	F_ERR_SERVICE_NOT_FOUND   = "Service %s not found in region %s"
+	F_ERR_SERVICE_UNHEALTHY   = "Service %s in region %s is unhealthy"
+	F_ERR_SERVICE_NOT_READY   = "Service %s in region %s is not ready"
+	F_ERR_SERVICE_DRAINING    = "Service %s in region %s is draining"
+	F_ERR_SERVICE_REMOVED     = "Service %s in region %s was removed at %s: %s"
+	F_ERR_SERVICE_NO_EXTERNAL = "Service %s in region %s has no external address"
+
+	F_ERR_REGISTRATION_INVALID_REGION   = "Region %s is invalid"
+	F_ERR_REGISTRATION_REGION_NOT_FOUND = "Region %s has no pathfinder"
+	F_ERR_REGISTRATION_NAME_COLLISION   = "Entry %s is also registered by services %s"
+
+	F_WARN_COLLISION_REJECTED = "%s of %s is published, %s rejected"
+	F_INFO_COLLISION_MERGED   = "%s of %s is merged with %s"
+	F_WARN_PANIC_THRESHOLD    = "Keeping entries, %d of %d entries of region %s would be dropped. Annotate pathfinder with %s=true to confirm"
 )
 
 type ErrCode int
@@ -28,4 +60,11 @@ const (
 	CODE_DUP_PF               ErrCode = 10000
 	CODE_REGION_NOT_FOUND     ErrCode = 10001
 	CODE_SVC_NAME_UNSPECIFIED ErrCode = 10002
+	CODE_SVC_NOT_FOUND        ErrCode = 10003
+	CODE_REGION_UNSPECIFIED   ErrCode = 10004
+	CODE_SVC_UNHEALTHY        ErrCode = 10005
+	CODE_SVC_NOT_READY        ErrCode = 10006
+	CODE_SVC_DRAINING         ErrCode = 10007
+	CODE_SVC_REMOVED          ErrCode = 10008
+	CODE_SVC_NO_EXTERNAL      ErrCode = 10009
 )
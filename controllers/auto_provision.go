@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "github.com/6BD-org/pathfinder/api/v1"
+	"github.com/6BD-org/pathfinder/consts"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// EventReasonProvisioned is emitted on auto provisioned pathfinders
+	EventReasonProvisioned = "Provisioned"
+	// EventReasonProvisionLimitReached is emitted on services whose region is not provisioned for limit
+	EventReasonProvisionLimitReached = "ProvisionLimitReached"
+)
+
+// autoProvision creates pathfinder of DEFAULT region in namespaces labeled with auto provision
+// label, and pathfinders of regions other than DEFAULT services are annotated with, up to
+// AutoProvisionRegionLimit regions in a namespace. DEFAULT is only provisioned by the label
+func (r *PathFinderReconciler) autoProvision(namespace string, svcs []corev1.Service, pfs []v1.PathFinder) {
+	ns := corev1.Namespace{}
+	err := r.Client.Get(context.TODO(), client.ObjectKey{Name: namespace}, &ns)
+	if err == nil && ns.Labels[v1.AutoProvisionLabel] == "true" && !hasRegion(pfs, v1.DefaultRegion) {
+		if r.provision(namespace, v1.DefaultRegion) {
+			pfs = append(pfs, v1.PathFinder{Spec: v1.PathFinderSpec{Region: v1.DefaultRegion}})
+		}
+	}
+	if r.AutoProvisionRegionLimit <= 0 {
+		return
+	}
+
+	provisioned := 0
+	for _, pf := range pfs {
+		if pf.Labels[v1.AutoProvisionedLabel] == "true" && !v1.SameRegion(pf.Spec.Region, v1.DefaultRegion) {
+			provisioned++
+		}
+	}
+	regions, waiting := orphanedRegions(svcs, pfs)
+	for _, region := range regions {
+		if provisioned >= r.AutoProvisionRegionLimit {
+			r.Log.Info(consts.WARN_PROVISION_LIMIT, "namespace", namespace, "region", region, "limit", r.AutoProvisionRegionLimit)
+			for _, svc := range waiting[region] {
+				r.event(svc, corev1.EventTypeWarning, EventReasonProvisionLimitReached,
+					fmt.Sprintf("Region %s is not provisioned, %d regions are provisioned already", region, provisioned))
+			}
+			continue
+		}
+		if r.provision(namespace, region) {
+			provisioned++
+		}
+	}
+}
+
+// provision creates an auto provisioned pathfinder of region, true if created
+func (r *PathFinderReconciler) provision(namespace string, region string) bool {
+	pf := &v1.PathFinder{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("pathfinder-%s", strings.ToLower(region)),
+			Namespace: namespace,
+			Labels:    map[string]string{v1.AutoProvisionedLabel: "true"},
+		},
+		Spec: v1.PathFinderSpec{Region: region},
+	}
+	if err := r.Client.Create(context.TODO(), pf); err != nil {
+		r.Log.Error(err, consts.ERR_PROVISION, "namespace", namespace, "region", region)
+		return false
+	}
+	r.Log.Info(consts.INFO_REGION_PROVISIONED, "namespace", namespace, "region", region, "pathfinder", pf.Name)
+	r.event(pf, corev1.EventTypeNormal, EventReasonProvisioned, fmt.Sprintf("Region %s is auto provisioned", region))
+	return true
+}
+
+// orphanedRegions returns sorted regions other than DEFAULT, which enabled services are annotated
+// with and have no pathfinder, along with services of each region. Regions deleted on purpose,
+// which services are marked orphaned of, are left out
+func orphanedRegions(svcs []corev1.Service, pfs []v1.PathFinder) ([]string, map[string][]*corev1.Service) {
+	waiting := make(map[string][]*corev1.Service)
+	for i := range svcs {
+		svc := &svcs[i]
+		if _, ok := svcRegistractionName(*svc); !ok || !svcPathFinderEnabled(*svc) {
+			continue
+		}
+		region, ok := svcRegion(*svc)
+		if !ok || v1.SameRegion(region, v1.DefaultRegion) {
+			continue
+		}
+		region = v1.NormalizeRegion(region)
+		if len(validation.IsDNS1123Label(strings.ToLower(region))) > 0 || hasRegion(pfs, region) {
+			continue
+		}
+		if deleted, ok := svc.Annotations[PathFinderOrphanedKey]; ok && v1.SameRegion(deleted, region) {
+			continue
+		}
+		waiting[region] = append(waiting[region], svc)
+	}
+	regions := make([]string, 0, len(waiting))
+	for region := range waiting {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+	return regions, waiting
+}
+
+// hasRegion tells if a pathfinder serves region, including one being deleted
+func hasRegion(pfs []v1.PathFinder, region string) bool {
+	for _, pf := range pfs {
+		if v1.SameRegion(pf.Spec.Region, region) {
+			return true
+		}
+	}
+	return false
+}
+
+// mapNamespace triggers reconciling a namespace when its labels change
+func (r *PathFinderReconciler) mapNamespace(obj client.Object) []reconcile.Request {
+	return []reconcile.Request{{NamespacedName: client.ObjectKey{Namespace: obj.GetName(), Name: obj.GetName()}}}
+}
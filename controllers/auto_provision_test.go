@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	v1 "github.com/6BD-org/pathfinder/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestAutoProvision(t *testing.T) {
+	ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testNamespace, Labels: map[string]string{v1.AutoProvisionLabel: "true"}}}
+	east := v1.PathFinder{ObjectMeta: metav1.ObjectMeta{Name: "east", Namespace: testNamespace}, Spec: v1.PathFinderSpec{Region: "EAST"}}
+	svcs := []corev1.Service{
+		newTestService("a", "a", "east"),
+		newTestService("b", "b", "west"),
+		newTestService("c", "c", "north"),
+		newTestService("d", "d", "south"),
+	}
+	svcs[3].Annotations[PathFinderOrphanedKey] = "SOUTH"
+
+	r, _ := newTestReconciler(&ns, &east)
+	r.AutoProvisionRegionLimit = 1
+	r.autoProvision(testNamespace, svcs, []v1.PathFinder{east})
+
+	pfl := v1.PathFinderList{}
+	_ = r.Client.List(context.TODO(), &pfl, client.InNamespace(testNamespace))
+	regions := []string{}
+	for _, pf := range pfl.Items {
+		regions = append(regions, pf.Spec.Region)
+	}
+	// NORTH is provisioned up to limit, SOUTH is deleted on purpose
+	if len(regions) != 3 || !hasRegion(pfl.Items, v1.DefaultRegion) || !hasRegion(pfl.Items, "NORTH") {
+		t.Fatalf("Unexpected regions %v", regions)
+	}
+}
+
+func TestAutoProvisionUnlabeled(t *testing.T) {
+	ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testNamespace}}
+	svcs := []corev1.Service{
+		newTestService("a", "a", ""),
+		newTestService("b", "b", "default"),
+		newTestService("c", "c", "north"),
+	}
+
+	r, _ := newTestReconciler(&ns)
+	r.AutoProvisionRegionLimit = 1
+	r.autoProvision(testNamespace, svcs, nil)
+
+	pfl := v1.PathFinderList{}
+	_ = r.Client.List(context.TODO(), &pfl, client.InNamespace(testNamespace))
+	// DEFAULT is only provisioned in labeled namespaces
+	if len(pfl.Items) != 1 || !hasRegion(pfl.Items, "NORTH") {
+		t.Fatalf("Unexpected pathfinders %v", pfl.Items)
+	}
+}
@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "github.com/6BD-org/pathfinder/api/v1"
+	"github.com/6BD-org/pathfinder/consts"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// EventReasonNameCollision is emitted on pathfinders and services losing entries for collisions
+	EventReasonNameCollision = "NameCollision"
+	// EventReasonHostsMerged is emitted on services whose hosts are merged into entries of others
+	EventReasonHostsMerged = "HostsMerged"
+)
+
+// olderService tells if a is created before b, names break ties
+func olderService(a *corev1.Service, b *corev1.Service) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+	return a.Name < b.Name
+}
+
+// resolveCollisions applies collision policy of pf to entries registered under the same name by
+// different services, owners[i] is the service of entries[i]. NameCollision condition of pf is
+// updated, and events are emitted when outcome of collisions changes
+func (r *PathFinderReconciler) resolveCollisions(pf *v1.PathFinder, entries []v1.ServiceEntry, owners []*corev1.Service) []v1.ServiceEntry {
+	policy := pf.Spec.EffectiveCollisionPolicy()
+	groups := make(map[string][]int)
+	names := make([]string, 0)
+	for i, entry := range entries {
+		if _, ok := groups[entry.ServiceName]; !ok {
+			names = append(names, entry.ServiceName)
+		}
+		groups[entry.ServiceName] = append(groups[entry.ServiceName], i)
+	}
+
+	resolved := make([]v1.ServiceEntry, 0, len(entries))
+	outcomes := make([]string, 0)
+	losers := make(map[*corev1.Service][]string)
+	for _, name := range names {
+		group := groups[name]
+		if len(group) == 1 {
+			resolved = append(resolved, entries[group[0]])
+			continue
+		}
+		sort.SliceStable(group, func(a, b int) bool { return olderService(owners[group[a]], owners[group[b]]) })
+
+		winner := entries[group[0]]
+		others := make([]string, 0, len(group)-1)
+		for _, i := range group[1:] {
+			others = append(others, owners[i].Name)
+			losers[owners[i]] = append(losers[owners[i]], name)
+		}
+		if policy == v1.CollisionMergeHosts {
+			winner = mergeEntries(entries, group)
+			if winner.HealthCheck != nil {
+				winner.Health = inheritHealth(pf.Status.ServiceEntries, winner)
+			}
+			outcomes = append(outcomes, fmt.Sprintf(consts.F_INFO_COLLISION_MERGED, name, owners[group[0]].Name, strings.Join(others, ",")))
+		} else {
+			outcomes = append(outcomes, fmt.Sprintf(consts.F_WARN_COLLISION_REJECTED, name, owners[group[0]].Name, strings.Join(others, ",")))
+		}
+		resolved = append(resolved, winner)
+	}
+
+	if len(outcomes) == 0 {
+		meta.SetStatusCondition(&pf.Status.Conditions, metav1.Condition{
+			Type:   v1.ConditionNameCollision,
+			Status: metav1.ConditionFalse,
+			Reason: v1.ReasonNoCollision,
+		})
+		return resolved
+	}
+
+	msg := strings.Join(outcomes, "; ")
+	if old := meta.FindStatusCondition(pf.Status.Conditions, v1.ConditionNameCollision); old == nil || old.Message != msg {
+		r.Log.Info(consts.WARN_NAME_COLLISION, "namespace", pf.Namespace, "region", pf.Spec.Region, "policy", policy, "msg", msg)
+		r.event(pf, corev1.EventTypeWarning, EventReasonNameCollision, msg)
+		for svc, names := range losers {
+			if policy == v1.CollisionMergeHosts {
+				r.event(svc, corev1.EventTypeNormal, EventReasonHostsMerged,
+					fmt.Sprintf("Hosts of entries %s are merged with services of the same names", strings.Join(names, ",")))
+			} else {
+				r.event(svc, corev1.EventTypeWarning, EventReasonNameCollision,
+					fmt.Sprintf("Entries %s are not published, older services are registered with the same names", strings.Join(names, ",")))
+			}
+		}
+	}
+	meta.SetStatusCondition(&pf.Status.Conditions, metav1.Condition{
+		Type:    v1.ConditionNameCollision,
+		Status:  metav1.ConditionTrue,
+		Reason:  string(policy),
+		Message: msg,
+	})
+	return resolved
+}
+
+// mergeEntries merges entries of group into the first one, hosts are joined in order of group.
+// Merged entry is ready if any of them is ready
+func mergeEntries(entries []v1.ServiceEntry, group []int) v1.ServiceEntry {
+	merged := *entries[group[0]].DeepCopy()
+	hosts := entries[group[0]].Hosts()
+	externalHosts := entries[group[0]].ExternalHosts()
+	for _, i := range group[1:] {
+		hosts = append(hosts, entries[i].Hosts()...)
+		externalHosts = append(externalHosts, entries[i].ExternalHosts()...)
+		merged.NotReady = merged.NotReady && entries[i].NotReady
+		if merged.ReadyEndpoints != nil && entries[i].ReadyEndpoints != nil {
+			ready := *merged.ReadyEndpoints + *entries[i].ReadyEndpoints
+			merged.ReadyEndpoints = &ready
+		}
+	}
+	merged.ServiceHost = strings.Join(hosts, ",")
+	merged.ExternalHost = strings.Join(externalHosts, ",")
+	return merged
+}
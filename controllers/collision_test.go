@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/6BD-org/pathfinder/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestResolveCollisions(t *testing.T) {
+	older := newTestService("orders", "orders", "")
+	older.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Hour))
+	newer := newTestService("orders-v2", "orders", "")
+	newer.CreationTimestamp = metav1.Now()
+	entries := []v1.ServiceEntry{
+		{ServiceName: "orders/http", ServiceHost: "orders-v2:80"},
+		{ServiceName: "orders/http", ServiceHost: "orders:80"},
+		{ServiceName: "billing", ServiceHost: "billing:80"},
+	}
+	owners := []*corev1.Service{&newer, &older, &older}
+
+	r, recorder := newTestReconciler()
+	pf := &v1.PathFinder{}
+	resolved := r.resolveCollisions(pf, entries, owners)
+	if len(resolved) != 2 || resolved[0].ServiceHost != "orders:80" {
+		t.Fatalf("Expecting oldest to win, got %v", resolved)
+	}
+	c := meta.FindStatusCondition(pf.Status.Conditions, v1.ConditionNameCollision)
+	if c == nil || c.Status != metav1.ConditionTrue || c.Reason != string(v1.CollisionOldestWins) {
+		t.Fatalf("Unexpected condition %v", c)
+	}
+	if len(recorder.Events) != 2 {
+		t.Fatalf("Expecting events on pathfinder and losing service, got %d", len(recorder.Events))
+	}
+	r.resolveCollisions(pf, entries, owners)
+	if len(recorder.Events) != 2 {
+		t.Fatal("Events should only be emitted when outcome changes")
+	}
+
+	pf.Spec.CollisionPolicy = v1.CollisionMergeHosts
+	resolved = r.resolveCollisions(pf, entries, owners)
+	if len(resolved) != 2 || resolved[0].ServiceHost != "orders:80,orders-v2:80" {
+		t.Fatalf("Expecting hosts to be merged oldest first, got %v", resolved)
+	}
+
+	resolved = r.resolveCollisions(pf, entries[2:], owners[2:])
+	c = meta.FindStatusCondition(pf.Status.Conditions, v1.ConditionNameCollision)
+	if len(resolved) != 1 || c.Status != metav1.ConditionFalse {
+		t.Fatalf("Expecting no collision, got %v", c)
+	}
+}
@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"time"
+
+	v1 "github.com/6BD-org/pathfinder/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultDrainGracePeriod is used when reconciler has no grace period configured
+	DefaultDrainGracePeriod = 30 * time.Second
+	// DefaultTombstoneRetention is used when reconciler has no tombstone retention configured
+	DefaultTombstoneRetention = 10 * time.Minute
+)
+
+func (r *PathFinderReconciler) drainGracePeriod() time.Duration {
+	if r.DrainGracePeriod > 0 {
+		return r.DrainGracePeriod
+	}
+	return DefaultDrainGracePeriod
+}
+
+func (r *PathFinderReconciler) tombstoneRetention() time.Duration {
+	if r.TombstoneRetention > 0 {
+		return r.TombstoneRetention
+	}
+	return DefaultTombstoneRetention
+}
+
+// removalReasons explains why entries of registered services are not published in
+// a region, entries not found here belong to deleted services
+func removalReasons(svcs []corev1.Service) map[string]string {
+	reasons := make(map[string]string)
+	for _, svc := range svcs {
+		name, ok := svcRegistractionName(svc)
+		if !ok {
+			continue
+		}
+		reason := v1.ReasonRegionChanged
+		if !svcPathFinderEnabled(svc) {
+			reason = v1.ReasonDeactivated
+		}
+		for _, p := range svc.Spec.Ports {
+			reasons[formatServiceName(name, p.Name)] = reason
+		}
+	}
+	return reasons
+}
+
+// drainEntries returns old entries which no longer present in entries, marked as draining
+// with reason from reasons, and entries draining longer than grace period, which are removed
+func drainEntries(oldEntries []v1.ServiceEntry, entries []v1.ServiceEntry, reasons map[string]string, now metav1.Time, grace time.Duration) ([]v1.ServiceEntry, []v1.ServiceEntry) {
+	present := make(map[string]bool)
+	for _, e := range entries {
+		present[e.ServiceName] = true
+	}
+
+	draining := make([]v1.ServiceEntry, 0)
+	removed := make([]v1.ServiceEntry, 0)
+	for _, old := range oldEntries {
+		if present[old.ServiceName] {
+			continue
+		}
+		if old.State != v1.EntryDraining || old.DrainingSince == nil {
+			old.State = v1.EntryDraining
+			old.DrainingSince = &now
+			old.Reason = v1.ReasonServiceDeleted
+			if reason, ok := reasons[old.ServiceName]; ok {
+				old.Reason = reason
+			}
+		}
+		if now.Sub(old.DrainingSince.Time) >= grace {
+			removed = append(removed, old)
+			continue
+		}
+		draining = append(draining, old)
+	}
+	return draining, removed
+}
+
+// buryEntries adds tombstones for removed entries. Tombstones older than retention,
+// or of entries registered again, are dropped
+func buryEntries(tombstones []v1.Tombstone, removed []v1.ServiceEntry, entries []v1.ServiceEntry, now metav1.Time, retention time.Duration) []v1.Tombstone {
+	present := make(map[string]bool)
+	for _, e := range entries {
+		present[e.ServiceName] = true
+	}
+
+	buried := make([]v1.Tombstone, 0)
+	for _, t := range tombstones {
+		if present[t.ServiceName] || now.Sub(t.RemovedAt.Time) >= retention {
+			continue
+		}
+		buried = append(buried, t)
+	}
+	for _, e := range removed {
+		buried = append(buried, v1.Tombstone{
+			ServiceName: e.ServiceName,
+			RemovedAt:   now,
+			Reason:      e.Reason,
+		})
+	}
+	if len(buried) == 0 {
+		return nil
+	}
+	return buried
+}
+
+// drainRequeue returns when the next draining entry, tombstone, pause or flap suppression
+// of pf expires, 0 if nothing expires
+func (r *PathFinderReconciler) drainRequeue(pf *v1.PathFinder) time.Duration {
+	var requeueAfter time.Duration
+	now := time.Now()
+	for _, e := range pf.Status.ServiceEntries {
+		if e.State != v1.EntryDraining || e.DrainingSince == nil {
+			continue
+		}
+		requeueAfter = minRequeue(requeueAfter, expiresIn(e.DrainingSince.Time, r.drainGracePeriod(), now))
+	}
+	for _, t := range pf.Status.Tombstones {
+		requeueAfter = minRequeue(requeueAfter, expiresIn(t.RemovedAt.Time, r.tombstoneRetention(), now))
+	}
+	if pf.Spec.IsPaused(now) && pf.Spec.PausedUntil != nil {
+		requeueAfter = minRequeue(requeueAfter, expiresIn(pf.Spec.PausedUntil.Time, 0, now))
+	}
+	for _, f := range pf.Status.Flaps {
+		if f.SuppressedUntil != nil {
+			requeueAfter = minRequeue(requeueAfter, expiresIn(f.SuppressedUntil.Time, 0, now))
+		}
+	}
+	return requeueAfter
+}
+
+func expiresIn(since time.Time, d time.Duration, now time.Time) time.Duration {
+	left := since.Add(d).Sub(now)
+	if left <= 0 {
+		return time.Second
+	}
+	return left
+}
+
+// minRequeue returns the sooner requeue, 0 means no requeue
+func minRequeue(a time.Duration, b time.Duration) time.Duration {
+	if a == 0 || (b > 0 && b < a) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"strconv"
+
+	v1 "github.com/6BD-org/pathfinder/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// ListNodes lists nodes of the cluster able to serve node ports, see nodeAvailable
+func (r *PathFinderReconciler) ListNodes() ([]corev1.Node, error) {
+	nodes := corev1.NodeList{}
+	if err := r.Client.List(context.TODO(), &nodes); err != nil {
+		return nil, err
+	}
+	available := make([]corev1.Node, 0, len(nodes.Items))
+	for _, node := range nodes.Items {
+		if nodeAvailable(node) {
+			available = append(available, node)
+		}
+	}
+	return available, nil
+}
+
+// nodeAvailable tells if a node is ready and schedulable, others are not published
+func nodeAvailable(node corev1.Node) bool {
+	if node.Spec.Unschedulable {
+		return false
+	}
+	for _, c := range node.Status.Conditions {
+		if c.Type == corev1.NodeReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// mapNode triggers reconciling namespaces with pathfinders publishing external addresses
+func (r *PathFinderReconciler) mapNode(obj client.Object) []reconcile.Request {
+	pfl := v1.PathFinderList{}
+	if err := r.Client.List(context.TODO(), &pfl); err != nil {
+		return nil
+	}
+	requests := []reconcile.Request{}
+	namespaces := make(map[string]bool)
+	for _, pf := range pfl.Items {
+		if !pf.Spec.ExternalAddresses || namespaces[pf.Namespace] {
+			continue
+		}
+		namespaces[pf.Namespace] = true
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKey{Namespace: pf.Namespace, Name: pf.Name}})
+	}
+	return requests
+}
+
+// nodeChanged filters node updates to ones changing what is published, heartbeats are ignored
+var nodeChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		old, ok := e.ObjectOld.(*corev1.Node)
+		if !ok {
+			return true
+		}
+		node, ok := e.ObjectNew.(*corev1.Node)
+		if !ok {
+			return true
+		}
+		return nodeAvailable(*old) != nodeAvailable(*node) ||
+			!reflect.DeepEqual(old.Status.Addresses, node.Status.Addresses)
+	},
+}
+
+// nodeAddress prefers external ip of a node, and falls back to internal ip
+func nodeAddress(node corev1.Node) (string, bool) {
+	for _, t := range []corev1.NodeAddressType{corev1.NodeExternalIP, corev1.NodeInternalIP} {
+		for _, addr := range node.Status.Addresses {
+			if addr.Type == t && len(addr.Address) > 0 {
+				return addr.Address, true
+			}
+		}
+	}
+	return "", false
+}
+
+// externalHosts returns addresses of a service port reachable from outside the cluster.
+// Load balancer ingresses are used if provisioned, otherwise node addresses with node port
+func externalHosts(svc corev1.Service, port corev1.ServicePort, nodes []corev1.Node) []string {
+	hosts := make([]string, 0)
+	if svc.Spec.Type == corev1.ServiceTypeLoadBalancer {
+		for _, ingress := range svc.Status.LoadBalancer.Ingress {
+			addr := ingress.IP
+			if len(addr) == 0 {
+				addr = ingress.Hostname
+			}
+			if len(addr) > 0 {
+				hosts = append(hosts, net.JoinHostPort(addr, strconv.Itoa(int(port.Port))))
+			}
+		}
+		if len(hosts) > 0 {
+			return hosts
+		}
+	}
+	if svc.Spec.Type != corev1.ServiceTypeNodePort && svc.Spec.Type != corev1.ServiceTypeLoadBalancer {
+		return hosts
+	}
+	if port.NodePort == 0 {
+		return hosts
+	}
+	for _, node := range nodes {
+		if addr, ok := nodeAddress(node); ok {
+			hosts = append(hosts, net.JoinHostPort(addr, strconv.Itoa(int(port.NodePort))))
+		}
+	}
+	return hosts
+}
+
+func hasExternalAddress(svc corev1.Service) bool {
+	return svc.Spec.Type == corev1.ServiceTypeNodePort || svc.Spec.Type == corev1.ServiceTypeLoadBalancer
+}
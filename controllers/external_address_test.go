@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"testing"
+
+	v1 "github.com/6BD-org/pathfinder/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestExternalHosts(t *testing.T) {
+	nodes := []corev1.Node{
+		{Status: corev1.NodeStatus{Addresses: []corev1.NodeAddress{
+			{Type: corev1.NodeInternalIP, Address: "10.0.0.1"},
+			{Type: corev1.NodeExternalIP, Address: "203.0.113.1"},
+		}}},
+		{Status: corev1.NodeStatus{Addresses: []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "fd00::2"}}}},
+	}
+	port := corev1.ServicePort{Port: 80, NodePort: 30080}
+
+	svc := corev1.Service{Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeNodePort}}
+	hosts := externalHosts(svc, port, nodes)
+	if len(hosts) != 2 || hosts[0] != "203.0.113.1:30080" || hosts[1] != "[fd00::2]:30080" {
+		t.Fatalf("Unexpected node port hosts %v", hosts)
+	}
+
+	svc.Spec.Type = corev1.ServiceTypeLoadBalancer
+	svc.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "198.51.100.1"}, {Hostname: "lb.example.com"}}
+	hosts = externalHosts(svc, port, nodes)
+	if len(hosts) != 2 || hosts[0] != "198.51.100.1:80" || hosts[1] != "lb.example.com:80" {
+		t.Fatalf("Unexpected load balancer hosts %v", hosts)
+	}
+}
+
+func TestListNodes(t *testing.T) {
+	node := func(name string, ready corev1.ConditionStatus, unschedulable bool) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       corev1.NodeSpec{Unschedulable: unschedulable},
+			Status:     corev1.NodeStatus{Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: ready}}},
+		}
+	}
+	external := v1.PathFinder{
+		ObjectMeta: metav1.ObjectMeta{Name: "pf", Namespace: "ext"},
+		Spec:       v1.PathFinderSpec{Region: "DEFAULT", ExternalAddresses: true},
+	}
+	internal := v1.PathFinder{
+		ObjectMeta: metav1.ObjectMeta{Name: "pf", Namespace: "int"},
+		Spec:       v1.PathFinderSpec{Region: "DEFAULT"},
+	}
+	r, _ := newTestReconciler(
+		node("ready", corev1.ConditionTrue, false),
+		node("not-ready", corev1.ConditionFalse, false),
+		node("cordoned", corev1.ConditionTrue, true),
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "unknown"}},
+		&external, &internal)
+
+	nodes, err := r.ListNodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 1 || nodes[0].Name != "ready" {
+		t.Fatalf("Expecting only ready and schedulable nodes, got %v", nodes)
+	}
+
+	requests := r.mapNode(node("ready", corev1.ConditionTrue, false))
+	if len(requests) != 1 || requests[0].Namespace != "ext" {
+		t.Fatalf("Expecting namespace with external addresses requeued, got %v", requests)
+	}
+}
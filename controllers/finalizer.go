@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	v1 "github.com/6BD-org/pathfinder/api/v1"
+	"github.com/6BD-org/pathfinder/consts"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// EventReasonRehomed is emitted on services moved to another region as their pathfinder is deleted
+	EventReasonRehomed = "Rehomed"
+	// EventReasonRegionDeleted is emitted on services orphaned as their pathfinder is deleted
+	EventReasonRegionDeleted = "RegionDeleted"
+	// EventReasonFinalized is emitted on pathfinders once their services are cleaned up
+	EventReasonFinalized = "Finalized"
+	// EventReasonFinalizeFailed is emitted on pathfinders whose services are not all cleaned up
+	EventReasonFinalizeFailed = "FinalizeFailed"
+)
+
+// finalize cleans up services of a pathfinder being deleted, and then removes finalizer.
+// Services are moved to region of rehome annotation if the region exists, or marked as orphaned.
+// Services failing to be patched are reported, and don't hold deletion of pathfinder
+func (r *PathFinderReconciler) finalize(pf *v1.PathFinder, svcs []corev1.Service, pfs []v1.PathFinder) error {
+	if !controllerutil.ContainsFinalizer(pf, v1.Finalizer) {
+		return nil
+	}
+
+	target, rehome := pf.Annotations[PathFinderRehomeToKey]
+	if rehome && !hasLiveRegion(pfs, target) {
+		r.Log.Info(consts.WARN_REHOME_REGION_NOT_FOUND, "namespace", pf.Namespace, "pathfinder", pf.Name, "region", target)
+		rehome = false
+	}
+
+	rehomed, orphaned := 0, 0
+	errs := []error{}
+	for i := range svcs {
+		svc := &svcs[i]
+		if _, ok := svcRegistractionName(*svc); !ok || !svcPathFinderEnabled(*svc) {
+			continue
+		}
+		region, ok := svcRegion(*svc)
+		if !ok {
+			region = PathFinderDefaultRegion
+		}
+		if !v1.SameRegion(region, pf.Spec.Region) {
+			continue
+		}
+
+		patch := client.MergeFrom(svc.DeepCopy())
+		if svc.Annotations == nil {
+			svc.Annotations = make(map[string]string)
+		}
+		if rehome {
+			svc.Annotations[PathFinderRegionKey] = target
+			delete(svc.Annotations, PathFinderOrphanedKey)
+		} else {
+			svc.Annotations[PathFinderOrphanedKey] = pf.Spec.Region
+		}
+		if err := r.Client.Patch(context.TODO(), svc, patch); err != nil {
+			r.Log.Error(err, consts.ERR_UPDATE_FAIL, "namespace", svc.Namespace, "svc", svc.Name)
+			errs = append(errs, fmt.Errorf("service %s: %v", svc.Name, err))
+			continue
+		}
+		if rehome {
+			rehomed++
+			r.event(svc, corev1.EventTypeNormal, EventReasonRehomed,
+				fmt.Sprintf("Region %s is deleted, service is moved to region %s", pf.Spec.Region, target))
+		} else {
+			orphaned++
+			r.event(svc, corev1.EventTypeWarning, EventReasonRegionDeleted,
+				fmt.Sprintf("Region %s is deleted, service is no longer discoverable", pf.Spec.Region))
+		}
+	}
+	r.event(pf, corev1.EventTypeNormal, EventReasonFinalized,
+		fmt.Sprintf("%d services rehomed, %d services orphaned", rehomed, orphaned))
+	if agg := utilerrors.NewAggregate(errs); agg != nil {
+		r.event(pf, corev1.EventTypeWarning, EventReasonFinalizeFailed,
+			fmt.Sprintf("%d services are not cleaned up: %v", len(errs), agg))
+	}
+
+	controllerutil.RemoveFinalizer(pf, v1.Finalizer)
+	return r.Client.Update(context.TODO(), pf)
+}
+
+// adoptOrphans removes orphaned mark of services whose region has a pathfinder again
+func (r *PathFinderReconciler) adoptOrphans(svcs []corev1.Service, pfs []v1.PathFinder) {
+	for i := range svcs {
+		svc := &svcs[i]
+		if _, ok := svc.Annotations[PathFinderOrphanedKey]; !ok {
+			continue
+		}
+		region, ok := svcRegion(*svc)
+		if !ok {
+			region = PathFinderDefaultRegion
+		}
+		if !hasLiveRegion(pfs, region) {
+			continue
+		}
+		patch := client.MergeFrom(svc.DeepCopy())
+		delete(svc.Annotations, PathFinderOrphanedKey)
+		if err := r.Client.Patch(context.TODO(), svc, patch); err != nil {
+			r.Log.Error(err, consts.ERR_UPDATE_FAIL, "namespace", svc.Namespace, "svc", svc.Name)
+		}
+	}
+}
+
+// hasLiveRegion tells if a pathfinder not being deleted serves region
+func hasLiveRegion(pfs []v1.PathFinder, region string) bool {
+	return liveRegion(pfs, region) != nil
+}
+
+// liveRegion returns pathfinder not being deleted serving region, nil if there is none
+func liveRegion(pfs []v1.PathFinder, region string) *v1.PathFinder {
+	for i := range pfs {
+		if pfs[i].DeletionTimestamp == nil && v1.SameRegion(pfs[i].Spec.Region, region) {
+			return &pfs[i]
+		}
+	}
+	return nil
+}
+
+func (r *PathFinderReconciler) event(obj runtime.Object, eventType string, reason string, msg string) {
+	if r.Recorder != nil {
+		r.Recorder.Event(obj, eventType, reason, msg)
+	}
+}
@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	v1 "github.com/6BD-org/pathfinder/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestFinalize(t *testing.T) {
+	now := metav1.Now()
+	east := v1.PathFinder{
+		ObjectMeta: metav1.ObjectMeta{Name: "east", Namespace: testNamespace, Finalizers: []string{v1.Finalizer}, DeletionTimestamp: &now},
+		Spec:       v1.PathFinderSpec{Region: "EAST"},
+	}
+	west := v1.PathFinder{
+		ObjectMeta: metav1.ObjectMeta{Name: "west", Namespace: testNamespace},
+		Spec:       v1.PathFinderSpec{Region: "WEST"},
+	}
+	a, b := newTestService("a", "a", "east"), newTestService("b", "b", "WEST")
+	disabled := newTestService("disabled", "disabled", "EAST")
+	disabled.Annotations[PathFinderAnnotationKey] = PathFinderDeactiveted
+	// Services deleted meanwhile fail to be patched
+	gone := newTestService("gone", "gone", "EAST")
+
+	r, recorder := newTestReconciler(&east, &west, &a, &b, &disabled)
+	svcs := []corev1.Service{gone, a, b, disabled}
+	if err := r.finalize(east.DeepCopy(), svcs, []v1.PathFinder{east, west}); err != nil {
+		t.Fatal(err)
+	}
+	failed := false
+	for _, e := range drainEvents(recorder) {
+		if strings.Contains(e, EventReasonFinalizeFailed) && strings.Contains(e, "gone") {
+			failed = true
+		}
+	}
+	if !failed {
+		t.Fatal("Expecting failure of cleaning up services reported")
+	}
+	untouched := corev1.Service{}
+	_ = r.Client.Get(context.TODO(), client.ObjectKey{Namespace: testNamespace, Name: "disabled"}, &untouched)
+	if _, ok := untouched.Annotations[PathFinderOrphanedKey]; ok {
+		t.Fatal("Disabled services should not be touched")
+	}
+	got := corev1.Service{}
+	_ = r.Client.Get(context.TODO(), client.ObjectKey{Namespace: testNamespace, Name: "a"}, &got)
+	if got.Annotations[PathFinderOrphanedKey] != "EAST" {
+		t.Fatalf("Expecting service to be orphaned, got %v", got.Annotations)
+	}
+	other := corev1.Service{}
+	_ = r.Client.Get(context.TODO(), client.ObjectKey{Namespace: testNamespace, Name: "b"}, &other)
+	if _, ok := other.Annotations[PathFinderOrphanedKey]; ok {
+		t.Fatal("Services of other regions should not be touched")
+	}
+	pf := v1.PathFinder{}
+	_ = r.Client.Get(context.TODO(), client.ObjectKey{Namespace: testNamespace, Name: "east"}, &pf)
+	if len(pf.Finalizers) != 0 {
+		t.Fatalf("Expecting finalizer to be removed, got %v", pf.Finalizers)
+	}
+
+	// Rehomed services are moved to the region and no longer orphaned
+	pf.Finalizers = []string{v1.Finalizer}
+	pf.Annotations = map[string]string{PathFinderRehomeToKey: "west"}
+	svcs = []corev1.Service{got, b}
+	if err := r.finalize(&pf, svcs, []v1.PathFinder{east, west}); err != nil {
+		t.Fatal(err)
+	}
+	rehomed := corev1.Service{}
+	_ = r.Client.Get(context.TODO(), client.ObjectKey{Namespace: testNamespace, Name: "a"}, &rehomed)
+	if rehomed.Annotations[PathFinderRegionKey] != "west" || rehomed.Annotations[PathFinderOrphanedKey] != "" {
+		t.Fatalf("Expecting service to be rehomed, got %v", rehomed.Annotations)
+	}
+}
@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	v1 "github.com/6BD-org/pathfinder/api/v1"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// testNamespace is the namespace of test fixtures
+const testNamespace = "test"
+
+func newTestScheme() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = coordinationv1.AddToScheme(scheme)
+	_ = v1.AddToScheme(scheme)
+	return scheme
+}
+
+// newTestReconciler builds a reconciler backed by a fake client holding objs, events are
+// kept by a fake recorder
+func newTestReconciler(objs ...runtime.Object) (*PathFinderReconciler, *record.FakeRecorder) {
+	recorder := record.NewFakeRecorder(10)
+	scheme := newTestScheme()
+	return &PathFinderReconciler{
+		Client:   fake.NewFakeClientWithScheme(scheme, objs...),
+		Log:      ctrl.Log,
+		Scheme:   scheme,
+		Recorder: recorder,
+	}, recorder
+}
+
+// newTestService builds an enabled service of test namespace registered as regName in region
+func newTestService(name string, regName string, region string) corev1.Service {
+	annotations := map[string]string{
+		PathFinderAnnotationKey:              PathFinderActivated,
+		PathFinderServiceRegistrationNameKey: regName,
+	}
+	if len(region) > 0 {
+		annotations[PathFinderRegionKey] = region
+	}
+	return corev1.Service{ObjectMeta: metav1.ObjectMeta{
+		Name:        name,
+		Namespace:   testNamespace,
+		Annotations: annotations,
+	}}
+}
+
+// drainEvents returns events recorded so far
+func drainEvents(recorder *record.FakeRecorder) []string {
+	events := []string{}
+	for len(recorder.Events) > 0 {
+		events = append(events, <-recorder.Events)
+	}
+	return events
+}
@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	v1 "github.com/6BD-org/pathfinder/api/v1"
+	"github.com/6BD-org/pathfinder/consts"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultFlapWindow is used when reconciler has no flap window configured
+	DefaultFlapWindow = 5 * time.Minute
+	// DefaultFlapSuppression is used when reconciler has no suppression period configured
+	DefaultFlapSuppression = 10 * time.Minute
+)
+
+func (r *PathFinderReconciler) flapWindow() time.Duration {
+	if r.FlapWindow > 0 {
+		return r.FlapWindow
+	}
+	return DefaultFlapWindow
+}
+
+func (r *PathFinderReconciler) flapSuppression() time.Duration {
+	if r.FlapSuppression > 0 {
+		return r.FlapSuppression
+	}
+	return DefaultFlapSuppression
+}
+
+// entrySignature describes what clients observe from an entry, empty if entry is absent
+func entrySignature(entry v1.ServiceEntry, present bool) string {
+	if !present {
+		return ""
+	}
+	return fmt.Sprintf("%s|%s|%v", entry.ServiceHost, entry.ExternalHost, entry.NotReady)
+}
+
+// dampFlaps counts changes of entries between reconciles. Once an entry changes FlapThreshold
+// times within flap window, it is held as it was published, until it stops changing for
+// the suppression period. Flap states are kept in pf status
+func (r *PathFinderReconciler) dampFlaps(pf *v1.PathFinder, oldEntries []v1.ServiceEntry, entries []v1.ServiceEntry, now metav1.Time) []v1.ServiceEntry {
+	if r.FlapThreshold <= 0 {
+		pf.Status.Flaps = nil
+		return entries
+	}
+
+	names := make([]string, 0)
+	desired := make(map[string]v1.ServiceEntry)
+	for _, e := range entries {
+		desired[e.ServiceName] = e
+		names = append(names, e.ServiceName)
+	}
+	published := make(map[string]v1.ServiceEntry)
+	for _, old := range oldEntries {
+		if old.IsDraining() {
+			continue
+		}
+		published[old.ServiceName] = old
+		if _, ok := desired[old.ServiceName]; !ok {
+			names = append(names, old.ServiceName)
+		}
+	}
+	// Suppressed entries may be neither desired nor published, their flap states are kept all the same
+	for _, f := range pf.Status.Flaps {
+		_, want := desired[f.ServiceName]
+		_, wasPublished := published[f.ServiceName]
+		if !want && !wasPublished && f.IsSuppressed(now.Time) {
+			names = append(names, f.ServiceName)
+		}
+	}
+
+	damped := make([]v1.ServiceEntry, 0)
+	flaps := make([]v1.FlapState, 0)
+	for _, name := range names {
+		d, want := desired[name]
+		p, wasPublished := published[name]
+		f, tracked := pf.Status.FindFlap(name)
+		if !tracked {
+			f = v1.FlapState{ServiceName: name, Observed: entrySignature(p, wasPublished)}
+		}
+
+		if sig := entrySignature(d, want); sig != f.Observed {
+			f.Observed = sig
+			if now.Sub(f.WindowStart.Time) >= r.flapWindow() {
+				f.WindowStart = now
+				f.Changes = 0
+			}
+			f.Changes++
+			// Changes during suppression extend it, so only stable entries are released
+			if f.Changes >= int32(r.FlapThreshold) || f.IsSuppressed(now.Time) {
+				if !f.IsSuppressed(now.Time) {
+					r.Log.Info(consts.INFO_ENTRY_FLAPPING, "namespace", pf.Namespace, "region", pf.Spec.Region, "entry", name, "changes", f.Changes)
+				}
+				until := metav1.NewTime(now.Add(r.flapSuppression()))
+				f.SuppressedUntil = &until
+			}
+		}
+
+		if f.IsSuppressed(now.Time) {
+			if wasPublished {
+				damped = append(damped, p)
+			}
+		} else {
+			if f.SuppressedUntil != nil {
+				f.SuppressedUntil = nil
+				f.Changes = 0
+			}
+			if want {
+				damped = append(damped, d)
+			}
+		}
+
+		if f.IsSuppressed(now.Time) || (f.Changes > 0 && now.Sub(f.WindowStart.Time) < r.flapWindow()) {
+			flaps = append(flaps, f)
+		}
+	}
+
+	if len(flaps) == 0 {
+		pf.Status.Flaps = nil
+	} else {
+		pf.Status.Flaps = flaps
+	}
+	return damped
+}
@@ -0,0 +1,230 @@
+package controllers
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "github.com/6BD-org/pathfinder/api/v1"
+	"github.com/6BD-org/pathfinder/consts"
+	"github.com/go-logr/logr"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	defaultHealthCheckInterval = 10 * time.Second
+	defaultHealthCheckTimeout  = 3 * time.Second
+	defaultHealthyThreshold    = 2
+	defaultUnhealthyThreshold  = 3
+
+	healthCheckTick = time.Second
+)
+
+// probeClient does not follow redirects, 3xx is healthy on its own. Like kubelet probes,
+// certificates of https entries are not verified
+var probeClient = &http.Client{
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+	Transport: &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	},
+}
+
+// probeState tracks consecutive probe results of one entry
+type probeState struct {
+	host      string
+	lastProbe time.Time
+	successes int32
+	failures  int32
+	probing   bool
+}
+
+// HealthChecker probes service entries with health check enabled,
+// and updates their health in pathfinder status
+type HealthChecker struct {
+	client.Client
+	Log logr.Logger
+
+	mu     sync.Mutex
+	states map[string]*probeState
+}
+
+// NeedLeaderElection makes sure only one manager is probing
+func (hc *HealthChecker) NeedLeaderElection() bool {
+	return true
+}
+
+// Start probes entries until ctx is done, it implements manager.Runnable
+func (hc *HealthChecker) Start(ctx context.Context) error {
+	hc.states = make(map[string]*probeState)
+	ticker := time.NewTicker(healthCheckTick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			hc.probeAll(ctx)
+		}
+	}
+}
+
+func (hc *HealthChecker) probeAll(ctx context.Context) {
+	pfl := v1.PathFinderList{}
+	if err := hc.List(ctx, &pfl); err != nil {
+		hc.Log.Error(err, consts.ERR_LIST_PATHFINDER)
+		return
+	}
+
+	seen := make(map[string]bool)
+	now := time.Now()
+	for _, pf := range pfl.Items {
+		// Health of entries is frozen as well while paused
+		if pf.Spec.IsPaused(now) {
+			continue
+		}
+		for _, entry := range pf.Status.ServiceEntries {
+			if entry.HealthCheck == nil {
+				continue
+			}
+			key := probeKey(&pf, entry.ServiceName)
+			seen[key] = true
+
+			hc.mu.Lock()
+			state, ok := hc.states[key]
+			if !ok || state.host != entry.ServiceHost {
+				state = &probeState{host: entry.ServiceHost}
+				hc.states[key] = state
+			}
+			due := !state.probing && now.Sub(state.lastProbe) >= entry.HealthCheck.Interval.Duration
+			if due {
+				state.probing = true
+				state.lastProbe = now
+			}
+			hc.mu.Unlock()
+
+			if due {
+				go hc.probeEntry(ctx, pf.Namespace, pf.Name, entry, state)
+			}
+		}
+	}
+
+	// Forget entries no longer checked
+	hc.mu.Lock()
+	for key := range hc.states {
+		if !seen[key] {
+			delete(hc.states, key)
+		}
+	}
+	hc.mu.Unlock()
+}
+
+func (hc *HealthChecker) probeEntry(ctx context.Context, namespace string, name string, entry v1.ServiceEntry, state *probeState) {
+	err := probe(ctx, entry)
+
+	hc.mu.Lock()
+	state.probing = false
+	if err == nil {
+		state.successes++
+		state.failures = 0
+	} else {
+		state.failures++
+		state.successes = 0
+	}
+	health := entry.Health
+	if state.successes >= entry.HealthCheck.HealthyThreshold {
+		health = v1.HealthHealthy
+	}
+	if state.failures >= entry.HealthCheck.UnhealthyThreshold {
+		health = v1.HealthUnhealthy
+	}
+	hc.mu.Unlock()
+
+	if health == entry.Health {
+		return
+	}
+	if err := hc.updateHealth(ctx, namespace, name, entry, health); err != nil {
+		hc.Log.Error(err, consts.ERR_HEALTH_UPDATE_FAIL, "namespace", namespace, "pathfinder", name, "entry", entry.ServiceName)
+		return
+	}
+	hc.Log.Info(consts.INFO_HEALTH_CHANGED, "namespace", namespace, "pathfinder", name, "entry", entry.ServiceName, "health", health)
+}
+
+func (hc *HealthChecker) updateHealth(ctx context.Context, namespace string, name string, entry v1.ServiceEntry, health v1.HealthState) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		pf := v1.PathFinder{}
+		if err := hc.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &pf); err != nil {
+			return err
+		}
+		for i := range pf.Status.ServiceEntries {
+			e := &pf.Status.ServiceEntries[i]
+			if e.ServiceName == entry.ServiceName && e.ServiceHost == entry.ServiceHost && e.HealthCheck != nil {
+				e.Health = health
+			}
+		}
+		return hc.Update(ctx, &pf)
+	})
+}
+
+func probe(ctx context.Context, entry v1.ServiceEntry) error {
+	ctx, cancel := context.WithTimeout(ctx, entry.HealthCheck.Timeout.Duration)
+	defer cancel()
+
+	for _, host := range entry.Hosts() {
+		var err error
+		switch entry.HealthCheck.Type {
+		case v1.HealthCheckHTTP:
+			err = probeHTTP(ctx, probeScheme(entry), host, entry.HealthCheck.Path)
+		default:
+			err = probeTCP(ctx, host)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func probeTCP(ctx context.Context, host string) error {
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", host)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// probeScheme is https for https entries, and http for others
+func probeScheme(entry v1.ServiceEntry) string {
+	if strings.EqualFold(entry.Scheme, "https") {
+		return "https"
+	}
+	return "http"
+}
+
+func probeHTTP(ctx context.Context, scheme string, host string, path string) error {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s://%s%s", scheme, host, path), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := probeClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return fmt.Errorf("Health check responded %s", resp.Status)
+	}
+	return nil
+}
+
+func probeKey(pf *v1.PathFinder, entryName string) string {
+	return fmt.Sprintf("%s/%s/%s", pf.Namespace, pf.Name, entryName)
+}
@@ -0,0 +1,39 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "github.com/6BD-org/pathfinder/api/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestProbeScheme(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	entry := v1.ServiceEntry{
+		ServiceName: "foo",
+		ServiceHost: strings.TrimPrefix(server.URL, "https://"),
+		Scheme:      "https",
+		HealthCheck: &v1.HealthCheck{
+			Type:    v1.HealthCheckHTTP,
+			Path:    "/healthz",
+			Timeout: metav1.Duration{Duration: 3 * time.Second},
+		},
+	}
+	if err := probe(context.TODO(), entry); err != nil {
+		t.Fatalf("Expecting https entry healthy, got %v", err)
+	}
+
+	entry.Scheme = ""
+	if err := probe(context.TODO(), entry); err == nil {
+		t.Fatal("Expecting plain http probe to fail against tls server")
+	}
+}
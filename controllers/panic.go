@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"fmt"
+
+	v1 "github.com/6BD-org/pathfinder/api/v1"
+	"github.com/6BD-org/pathfinder/consts"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultPanicThreshold is the percentage of active entries of a region
+	// allowed to be dropped in one reconcile, 100 disables panic mode
+	DefaultPanicThreshold = 50
+	// DefaultPanicMinEntries is the number of active entries a region needs to be protected,
+	// so that deleting the only service of a small region needs no confirmation
+	DefaultPanicMinEntries = 4
+)
+
+func (r *PathFinderReconciler) panicThreshold() int {
+	if r.PanicThreshold > 0 {
+		return r.PanicThreshold
+	}
+	return DefaultPanicThreshold
+}
+
+func (r *PathFinderReconciler) panicMinEntries() int {
+	if r.PanicMinEntries > 0 {
+		return r.PanicMinEntries
+	}
+	return DefaultPanicMinEntries
+}
+
+// droppedEntries counts active entries in oldEntries, and those of them missing from entries
+func droppedEntries(oldEntries []v1.ServiceEntry, entries []v1.ServiceEntry) (int, int) {
+	present := make(map[string]bool)
+	for _, e := range entries {
+		present[e.ServiceName] = true
+	}
+	active, dropped := 0, 0
+	for _, old := range oldEntries {
+		if old.IsDraining() {
+			continue
+		}
+		active++
+		if !present[old.ServiceName] {
+			dropped++
+		}
+	}
+	return active, dropped
+}
+
+func exceedsPanicThreshold(active int, dropped int, threshold int, minEntries int) bool {
+	return active >= minEntries && dropped*100 > active*threshold
+}
+
+// consumeConfirmation removes confirmation annotation from pf, true is returned if
+// dropping entries past panic threshold is confirmed
+func consumeConfirmation(pf *v1.PathFinder) bool {
+	v, ok := pf.Annotations[PathFinderConfirmDeregistrationKey]
+	if !ok {
+		return false
+	}
+	delete(pf.Annotations, PathFinderConfirmDeregistrationKey)
+	return v == "true"
+}
+
+// panicking tells if entries should be kept as they are, instead of being replaced by entries.
+// Degraded condition of pf is updated, and an event is emitted when pathfinder starts panicking
+func (r *PathFinderReconciler) panicking(pf *v1.PathFinder, oldEntries []v1.ServiceEntry, entries []v1.ServiceEntry) bool {
+	confirmed := consumeConfirmation(pf)
+	active, dropped := droppedEntries(oldEntries, entries)
+	if confirmed || !exceedsPanicThreshold(active, dropped, r.panicThreshold(), r.panicMinEntries()) {
+		meta.SetStatusCondition(&pf.Status.Conditions, metav1.Condition{
+			Type:   v1.ConditionDegraded,
+			Status: metav1.ConditionFalse,
+			Reason: v1.ReasonReconciled,
+		})
+		return false
+	}
+
+	msg := fmt.Sprintf(consts.F_WARN_PANIC_THRESHOLD, dropped, active, pf.Spec.Region, PathFinderConfirmDeregistrationKey)
+	r.Log.Info(consts.WARN_PANIC_THRESHOLD, "namespace", pf.Namespace, "region", pf.Spec.Region, "active", active, "dropped", dropped)
+	if !meta.IsStatusConditionTrue(pf.Status.Conditions, v1.ConditionDegraded) && r.Recorder != nil {
+		r.Recorder.Event(pf, corev1.EventTypeWarning, v1.ReasonPanicThreshold, msg)
+	}
+	meta.SetStatusCondition(&pf.Status.Conditions, metav1.Condition{
+		Type:    v1.ConditionDegraded,
+		Status:  metav1.ConditionTrue,
+		Reason:  v1.ReasonPanicThreshold,
+		Message: msg,
+	})
+	return true
+}
@@ -18,16 +18,23 @@ package controllers
 
 import (
 	"context"
+	"time"
 
 	v1 "github.com/6BD-org/pathfinder/api/v1"
 	"github.com/6BD-org/pathfinder/consts"
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1beta1 "k8s.io/api/discovery/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 const (
@@ -40,7 +47,34 @@ const (
 	PathFinderActivated = "Activated"
 	// PathFinderDeactiveted indicates that this service is hidden from discovery
 	PathFinderDeactiveted   = "Deactivated"
-	PathFinderDefaultRegion = "DEFAULT"
+	PathFinderDefaultRegion = v1.DefaultRegion
+
+	// PathFinderHealthCheckKey enables health checking of a service, value is TCP or HTTP
+	PathFinderHealthCheckKey                   = "XM-PathFinder-HealthCheck"
+	PathFinderHealthCheckPathKey               = "XM-PathFinder-HealthCheckPath"
+	PathFinderHealthCheckIntervalKey           = "XM-PathFinder-HealthCheckInterval"
+	PathFinderHealthCheckTimeoutKey            = "XM-PathFinder-HealthCheckTimeout"
+	PathFinderHealthCheckHealthyThresholdKey   = "XM-PathFinder-HealthCheckHealthyThreshold"
+	PathFinderHealthCheckUnhealthyThresholdKey = "XM-PathFinder-HealthCheckUnhealthyThreshold"
+
+	// PathFinderMinReadyEndpointsKey enables readiness gating, a service is only
+	// published when it has at least this number of ready endpoints
+	PathFinderMinReadyEndpointsKey = "XM-PathFinder-MinReadyEndpoints"
+	// PathFinderReadinessPolicyKey is Exclude or Mark, see ReadinessPolicyExclude and ReadinessPolicyMark
+	PathFinderReadinessPolicyKey = "XM-PathFinder-ReadinessPolicy"
+
+	// PathFinderSchemeKey overrides scheme of entries, either one scheme for all ports
+	// like https, or schemes by port name like web=https,api=grpc
+	PathFinderSchemeKey = "XM-PathFinder-Scheme"
+
+	// PathFinderRehomeToKey on a pathfinder moves its services to another region when it is deleted
+	PathFinderRehomeToKey = "XM-PathFinder-RehomeTo"
+	// PathFinderOrphanedKey marks services whose region is deleted, value is the deleted region
+	PathFinderOrphanedKey = "XM-PathFinder-Orphaned"
+
+	// PathFinderConfirmDeregistrationKey on a pathfinder confirms dropping entries past
+	// panic threshold, it is removed once consumed
+	PathFinderConfirmDeregistrationKey = "XM-PathFinder-ConfirmDeregistration"
 )
 
 // PathFinderReconciler reconciles a PathFinder object
@@ -48,11 +82,39 @@ type PathFinderReconciler struct {
 	client.Client
 	Log    logr.Logger
 	Scheme *runtime.Scheme
+	// APIReader reads objects not worth caching, such as leases, falls back to Client if not set
+	APIReader client.Reader
+
+	// DrainGracePeriod is how long entries of deactivated or deleted services stay draining
+	DrainGracePeriod time.Duration
+	// TombstoneRetention is how long tombstones of removed entries are kept
+	TombstoneRetention time.Duration
+	// PanicThreshold is the percentage of active entries of a region allowed to be dropped in one reconcile
+	PanicThreshold int
+	// PanicMinEntries is the number of active entries a region needs to be protected by panic threshold
+	PanicMinEntries int
+	// FlapThreshold is the number of changes within FlapWindow to suppress an entry, 0 disables flap damping
+	FlapThreshold int
+	FlapWindow    time.Duration
+	// FlapSuppression is how long a flapping entry needs to be stable before its changes are published
+	FlapSuppression time.Duration
+	// RegistrationResync is how often registration annotations of services are refreshed when unchanged
+	RegistrationResync time.Duration
+	// AutoProvisionRegionLimit is the number of regions other than DEFAULT auto provisioned in
+	// a namespace for services annotated with them, 0 disables provisioning regions of services
+	AutoProvisionRegionLimit int
+
+	Recorder record.EventRecorder
 }
 
 // +kubebuilder:rbac:groups=xmbsmdsj.com,resources=pathfinders,verbs=get;list;watch;create;update;patch;delete
-// +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups=discovery.k8s.io,resources=endpointslices,verbs=get;list;watch
 // +kubebuilder:rbac:groups=xmbsmdsj.com,resources=pathfinders/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=xmbsmdsj.com,resources=pathfinders/finalizers,verbs=update
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 // +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile is the main logic of interpreting PathFinder CRDs
@@ -63,6 +125,7 @@ func (r *PathFinderReconciler) Reconcile(ctx context.Context, req reconcile.Requ
 	svcMap := make(map[string][]corev1.Service)
 
 	serviceList := r.ListServices(req.Namespace)
+	reasons := removalReasons(serviceList.Items)
 
 	for _, svc := range serviceList.Items {
 		enabled := verify(&svc)
@@ -70,6 +133,7 @@ func (r *PathFinderReconciler) Reconcile(ctx context.Context, req reconcile.Requ
 			continue
 		}
 		region, _ := svcRegion(svc)
+		region = v1.NormalizeRegion(region)
 		_, ok := svcMap[region]
 		if !ok {
 			svcMap[region] = make([]corev1.Service, 0)
@@ -78,32 +142,83 @@ func (r *PathFinderReconciler) Reconcile(ctx context.Context, req reconcile.Requ
 
 	}
 
+	// Regions without services are also rebuilt, so that removed services are drained
+	regions := make(map[string]bool)
 	for region := range svcMap {
-		svcs, ok := svcMap[region]
-		pathFinderRegion, err := r.GetPathFinderRegion(req.Namespace, region)
-		oldPathFinderRegion := pathFinderRegion.DeepCopy()
+		regions[region] = true
+	}
+	pfl, err := r.ListPathFinders(req.Namespace)
+	if err != nil {
+		r.Log.Error(err, consts.ERR_LIST_PATHFINDER, "msg", err.Error())
+		return ctrl.Result{}, err
+	}
+	for i := range pfl.Items {
+		if pfl.Items[i].DeletionTimestamp != nil {
+			if err := r.finalize(&pfl.Items[i], serviceList.Items, pfl.Items); err != nil {
+				r.Log.Error(err, consts.ERR_FINALIZE, "namespace", req.Namespace, "pathfinder", pfl.Items[i].Name)
+			}
+			continue
+		}
+		regions[v1.NormalizeRegion(pfl.Items[i].Spec.Region)] = true
+		if err := r.ensureRegionLease(&pfl.Items[i]); err != nil {
+			r.Log.Error(err, consts.ERR_REGION_LEASE, "namespace", req.Namespace, "pathfinder", pfl.Items[i].Name)
+		}
+	}
 
+	r.releaseStaleLeases(req.Namespace, pfl.Items, time.Now())
+	r.adoptOrphans(serviceList.Items, pfl.Items)
+	r.autoProvision(req.Namespace, serviceList.Items, pfl.Items)
+
+	var requeueAfter time.Duration
+	// Status is also written by health checker, updates losing to it are retried at once
+	var updateErr error
+	conflicted := false
+	published := make(map[string]*v1.PathFinder)
+	for region := range regions {
+		svcs := svcMap[region]
+		pathFinderRegion, err := r.GetPathFinderRegion(req.Namespace, region)
 		if err != nil {
 			r.Log.Error(err, consts.ERR_GET_PATHFINDER_REGION, "msg", err.Error())
 			continue
 		}
-		if ok {
-			r.RebuildPathfinderRegion(pathFinderRegion, svcs)
-			if r.shouldUpdate(oldPathFinderRegion, pathFinderRegion) {
-				err := r.Update(context.TODO(), pathFinderRegion)
-				if err != nil {
-					r.Log.Error(
-						errors.Errorf(consts.ERR_UPDATE_FAIL),
-						consts.ERR_UPDATE_FAIL,
-						"msg", err.Error(),
-					)
+		if pathFinderRegion.DeletionTimestamp != nil {
+			continue
+		}
+		oldPathFinderRegion := pathFinderRegion.DeepCopy()
+
+		if err := r.rebuildPathfinderRegion(pathFinderRegion, svcs, reasons); err != nil {
+			r.Log.Error(err, consts.ERR_REBUILD_REGION, "region", region)
+			continue
+		}
+		if r.shouldUpdate(oldPathFinderRegion, pathFinderRegion) {
+			err := r.Update(context.TODO(), pathFinderRegion)
+			if err != nil {
+				r.Log.Error(
+					errors.Errorf(consts.ERR_UPDATE_FAIL),
+					consts.ERR_UPDATE_FAIL,
+					"msg", err.Error(),
+				)
+				if apierrors.IsConflict(err) {
+					conflicted = true
+				} else if updateErr == nil {
+					updateErr = err
 				}
 			}
 		}
-
+		published[region] = pathFinderRegion
+		requeueAfter = minRequeue(requeueAfter, r.drainRequeue(pathFinderRegion))
 	}
+	r.reportRegistrations(serviceList.Items, published)
+	requeueAfter = minRequeue(requeueAfter, r.registrationResync())
 
-	return ctrl.Result{}, nil
+	if updateErr != nil {
+		return ctrl.Result{}, updateErr
+	}
+	// RequeueAfter takes precedence over Requeue, so they are not set together
+	if conflicted {
+		return ctrl.Result{Requeue: true}, nil
+	}
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
 }
 
 func (r *PathFinderReconciler) SetupWithManager(mgr ctrl.Manager) error {
@@ -113,5 +228,18 @@ func (r *PathFinderReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	}
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&corev1.Service{}).
+		Watches(
+			&source.Kind{Type: &discoveryv1beta1.EndpointSlice{}},
+			handler.EnqueueRequestsFromMapFunc(r.mapEndpointSliceToService),
+		).
+		Watches(
+			&source.Kind{Type: &corev1.Namespace{}},
+			handler.EnqueueRequestsFromMapFunc(r.mapNamespace),
+		).
+		Watches(
+			&source.Kind{Type: &corev1.Node{}},
+			handler.EnqueueRequestsFromMapFunc(r.mapNode),
+			builder.WithPredicates(nodeChanged),
+		).
 		Complete(r)
 }
@@ -3,12 +3,19 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"net"
 	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
 
 	v1 "github.com/6BD-org/pathfinder/api/v1"
 	"github.com/6BD-org/pathfinder/common"
 	"github.com/6BD-org/pathfinder/consts"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -38,43 +45,169 @@ func (r *PathFinderReconciler) ListServices(namespace string) *corev1.ServiceLis
 
 func (r *PathFinderReconciler) shouldUpdate(oldPf *v1.PathFinder, pf *v1.PathFinder) bool {
 	return (!reflect.DeepEqual(pf.Spec, oldPf.Spec)) ||
-		(!reflect.DeepEqual(pf.Status, oldPf.Status))
+		(!reflect.DeepEqual(pf.Status, oldPf.Status)) ||
+		(!reflect.DeepEqual(pf.Annotations, oldPf.Annotations))
 }
 
 // RebuildPathfinderRegion Rebuild pathfinder from services from that region
 func (r *PathFinderReconciler) RebuildPathfinderRegion(pf *v1.PathFinder, svcs []corev1.Service) error {
+	return r.rebuildPathfinderRegion(pf, svcs, nil)
+}
+
+// rebuildPathfinderRegion rebuilds pathfinder. If pathfinder is paused, its status is kept
+// and changes are only summarized as pending
+func (r *PathFinderReconciler) rebuildPathfinderRegion(pf *v1.PathFinder, svcs []corev1.Service, reasons map[string]string) error {
+	if !pf.Spec.IsPaused(time.Now()) {
+		pf.Status.Pending = nil
+		return r.buildPathfinderRegion(pf, svcs, reasons)
+	}
+	built := pf.DeepCopy()
+	if err := r.buildPathfinderRegion(built, svcs, reasons); err != nil {
+		return err
+	}
+	pf.Status.Pending = pendingChanges(pf.Status.ServiceEntries, built.Status.ServiceEntries)
+	// Collisions are reported as detected, even though their outcome is not published yet
+	if c := meta.FindStatusCondition(built.Status.Conditions, v1.ConditionNameCollision); c != nil {
+		meta.SetStatusCondition(&pf.Status.Conditions, *c)
+	}
+	if pf.Status.Pending != nil {
+		r.Log.Info(consts.INFO_REGION_PAUSED, "namespace", pf.Namespace, "region", pf.Spec.Region, "reason", pf.Spec.PauseReason)
+	}
+	return nil
+}
+
+// buildPathfinderRegion builds entries from services, removed entries are drained
+// and then buried with reasons, see removalReasons
+func (r *PathFinderReconciler) buildPathfinderRegion(pf *v1.PathFinder, svcs []corev1.Service, reasons map[string]string) error {
+	oldEntries := pf.Status.ServiceEntries
 	svcEntries := make([]v1.ServiceEntry, 0)
-	for _, svc := range svcs {
+	owners := make([]*corev1.Service, 0)
+	hostTemplate, err := v1.ParseHostTemplate(pf.Spec.EffectiveHostTemplate())
+	if err != nil {
+		return err
+	}
+	if reasons == nil {
+		reasons = make(map[string]string)
+	}
+	var nodes []corev1.Node
+	if pf.Spec.ExternalAddresses {
+		if nodes, err = r.ListNodes(); err != nil {
+			return err
+		}
+	}
+	for i := range svcs {
+		svc := svcs[i]
 		region, ok := svcRegion(svc)
 		if !ok {
 			r.Log.Info(consts.WARN_REGION_UNSPECIFIED)
-			region = "DEFAULT"
+			region = PathFinderDefaultRegion
 		}
-		if region != pf.Spec.Region {
+		if !v1.SameRegion(region, pf.Spec.Region) {
 			r.Log.Info(consts.WARN_REGION_INCONSISTENT, "namespace", svc.Namespace, "svc", svc.Name)
 		} else {
+			minReady, gated, err := svcMinReadyEndpoints(svc)
+			if err != nil {
+				r.Log.Info(consts.WARN_INVALID_MIN_READY, "namespace", svc.Namespace, "svc", svc.Name, "msg", err.Error())
+			}
+			readyEndpoints := make(map[string]int32)
+			if gated {
+				if readyEndpoints, err = r.ReadyEndpoints(svc); err != nil {
+					return err
+				}
+			}
 			for _, p := range svc.Spec.Ports {
 				name, _ := svcRegistractionName(svc)
+				host, err := buildHostFromService(hostTemplate, pf, svc, p)
+				if err != nil {
+					return err
+				}
 				entry := v1.ServiceEntry{
 					ServiceName: formatServiceName(name, p.Name),
-					ServiceHost: buildURLFromService(svc, p.Port),
+					ServiceHost: host,
 					Payload: v1.Payload{
 						KeyValPairs: make([]v1.PayloadKeyValPair, 0),
 					},
 				}
+				entry.Protocol = string(p.Protocol)
+				if p.AppProtocol != nil {
+					entry.AppProtocol = *p.AppProtocol
+				}
+				if p.TargetPort.IntValue() != 0 || len(p.TargetPort.StrVal) > 0 {
+					entry.TargetPort = p.TargetPort.String()
+				}
+				entry.Scheme = svcScheme(svc, p)
+				entry.KubeServiceName = svc.Name
+				entry.Port = p.Port
+				if pf.Spec.ExternalAddresses && hasExternalAddress(svc) {
+					entry.ExternalHost = strings.Join(externalHosts(svc, p, nodes), ",")
+				}
+				healthCheck, err := svcHealthCheck(svc)
+				if err != nil {
+					r.Log.Info(consts.WARN_INVALID_HEALTH_CHECK, "namespace", svc.Namespace, "svc", svc.Name, "msg", err.Error())
+				}
+				if healthCheck != nil {
+					entry.HealthCheck = healthCheck
+					entry.Health = inheritHealth(oldEntries, entry)
+				}
+				if gated {
+					ready := readyEndpoints[p.Name]
+					entry.ReadyEndpoints = &ready
+					if ready < minReady {
+						if svcReadinessPolicy(svc) == ReadinessPolicyExclude {
+							r.Log.Info(consts.INFO_ENTRY_NOT_READY, "namespace", svc.Namespace, "svc", svc.Name, "entry", entry.ServiceName)
+							reasons[entry.ServiceName] = v1.ReasonNotReady
+							continue
+						}
+						entry.NotReady = true
+					}
+				}
 				svcEntries = append(svcEntries, entry)
-
+				owners = append(owners, &svcs[i])
 			}
 		}
 
 	}
-	pf.Status.ServiceEntries = svcEntries
+	svcEntries = r.resolveCollisions(pf, svcEntries, owners)
+	svcEntries = r.mergeStaticEntries(pf, svcEntries)
+	staticRemovalReasons(pf, oldEntries, reasons)
+
+	now := metav1.Now()
+	svcEntries = r.dampFlaps(pf, oldEntries, svcEntries, now)
+	if r.panicking(pf, oldEntries, svcEntries) {
+		return nil
+	}
+	draining, removed := drainEntries(oldEntries, svcEntries, reasons, now, r.drainGracePeriod())
+	pf.Status.Tombstones = buryEntries(pf.Status.Tombstones, removed, svcEntries, now, r.tombstoneRetention())
+	pf.Status.ServiceEntries = append(svcEntries, draining...)
 	return nil
 }
 
-//BuildURLFromService build a domain name from a service
-func buildURLFromService(service corev1.Service, port int32) string {
-	return fmt.Sprintf("%s.%s.svc:%v", service.Name, service.Namespace, port)
+// buildHostFromService renders host of a service port with host template of pf
+func buildHostFromService(tmpl *template.Template, pf *v1.PathFinder, service corev1.Service, port corev1.ServicePort) (string, error) {
+	vars := v1.HostTemplateVars{
+		Name:          service.Name,
+		Namespace:     service.Namespace,
+		Port:          port.Port,
+		ClusterDomain: pf.Spec.ClusterDomain,
+		ClusterIP:     service.Spec.ClusterIP,
+	}
+	vars.Scheme = svcScheme(service, port)
+	clusterIPs := service.Spec.ClusterIPs
+	if len(clusterIPs) == 0 && len(service.Spec.ClusterIP) > 0 {
+		clusterIPs = []string{service.Spec.ClusterIP}
+	}
+	for _, ip := range clusterIPs {
+		parsed := net.ParseIP(ip)
+		if parsed == nil {
+			continue
+		}
+		if parsed.To4() != nil {
+			vars.ClusterIPv4 = ip
+		} else {
+			vars.ClusterIPv6 = ip
+		}
+	}
+	return v1.ExecuteHostTemplate(tmpl, vars)
 }
 
 func formatServiceName(service string, portName string) string {
@@ -84,6 +217,26 @@ func formatServiceName(service string, portName string) string {
 	return fmt.Sprintf("%s/%s", service, portName)
 }
 
+// svcScheme returns scheme of a service port, scheme annotation takes precedence over
+// app protocol, standard app protocols like kubernetes.io/h2c are reduced to h2c
+func svcScheme(svc corev1.Service, port corev1.ServicePort) string {
+	if v, ok := svc.Annotations[PathFinderSchemeKey]; ok {
+		if !strings.Contains(v, "=") {
+			return strings.ToLower(strings.TrimSpace(v))
+		}
+		for _, pair := range strings.Split(v, ",") {
+			kv := strings.SplitN(pair, "=", 2)
+			if len(kv) == 2 && strings.TrimSpace(kv[0]) == port.Name {
+				return strings.ToLower(strings.TrimSpace(kv[1]))
+			}
+		}
+	}
+	if port.AppProtocol == nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(*port.AppProtocol), "kubernetes.io/")
+}
+
 func svcRegion(svc corev1.Service) (string, bool) {
 	k, ok := svc.Annotations[PathFinderRegionKey]
 	return k, ok
@@ -94,6 +247,87 @@ func svcRegistractionName(svc corev1.Service) (string, bool) {
 	return n, ok
 }
 
+// svcHealthCheck parses health check annotations, nil is returned if health check is disabled
+func svcHealthCheck(svc corev1.Service) (*v1.HealthCheck, error) {
+	t, ok := svc.Annotations[PathFinderHealthCheckKey]
+	if !ok {
+		return nil, nil
+	}
+	hc := &v1.HealthCheck{
+		Type:               v1.HealthCheckType(strings.ToUpper(t)),
+		Path:               svc.Annotations[PathFinderHealthCheckPathKey],
+		Interval:           metav1.Duration{Duration: defaultHealthCheckInterval},
+		Timeout:            metav1.Duration{Duration: defaultHealthCheckTimeout},
+		HealthyThreshold:   defaultHealthyThreshold,
+		UnhealthyThreshold: defaultUnhealthyThreshold,
+	}
+	switch hc.Type {
+	case v1.HealthCheckTCP:
+	case v1.HealthCheckHTTP:
+		if len(hc.Path) == 0 {
+			hc.Path = "/"
+		}
+	default:
+		return nil, fmt.Errorf("Unknown health check type %s", t)
+	}
+
+	var err error
+	if v, ok := svc.Annotations[PathFinderHealthCheckIntervalKey]; ok {
+		if hc.Interval.Duration, err = parsePositiveDuration(v); err != nil {
+			return nil, err
+		}
+	}
+	if v, ok := svc.Annotations[PathFinderHealthCheckTimeoutKey]; ok {
+		if hc.Timeout.Duration, err = parsePositiveDuration(v); err != nil {
+			return nil, err
+		}
+	}
+	if v, ok := svc.Annotations[PathFinderHealthCheckHealthyThresholdKey]; ok {
+		if hc.HealthyThreshold, err = parseThreshold(v); err != nil {
+			return nil, err
+		}
+	}
+	if v, ok := svc.Annotations[PathFinderHealthCheckUnhealthyThresholdKey]; ok {
+		if hc.UnhealthyThreshold, err = parseThreshold(v); err != nil {
+			return nil, err
+		}
+	}
+	return hc, nil
+}
+
+func parsePositiveDuration(v string) (time.Duration, error) {
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("Duration must be positive, got %s", v)
+	}
+	return d, nil
+}
+
+func parseThreshold(v string) (int32, error) {
+	n, err := strconv.ParseInt(v, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	if n < 1 {
+		return 0, fmt.Errorf("Threshold must be positive, got %d", n)
+	}
+	return int32(n), nil
+}
+
+// inheritHealth keeps health of an entry across rebuilds, as long as it is probing the same host
+func inheritHealth(oldEntries []v1.ServiceEntry, entry v1.ServiceEntry) v1.HealthState {
+	for _, old := range oldEntries {
+		if old.ServiceName == entry.ServiceName && old.ServiceHost == entry.ServiceHost &&
+			old.HealthCheck != nil && len(old.Health) > 0 {
+			return old.Health
+		}
+	}
+	return v1.HealthUnknown
+}
+
 func svcPathFinderEnabled(svc corev1.Service) bool {
 	p, ok := svc.Annotations[PathFinderAnnotationKey]
 	if !ok {
@@ -117,7 +351,7 @@ func verify(svc *corev1.Service) bool {
 
 	_, ok = svcRegion(*svc)
 	if !ok {
-		svc.Annotations[PathFinderRegionKey] = "DEFAULT"
+		svc.Annotations[PathFinderRegionKey] = PathFinderDefaultRegion
 	}
 
 	return true
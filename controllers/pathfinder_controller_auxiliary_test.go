@@ -0,0 +1,303 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/6BD-org/pathfinder/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1beta1 "k8s.io/api/discovery/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestSvcHealthCheck(t *testing.T) {
+	svc := corev1.Service{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+		PathFinderHealthCheckKey:                   "http",
+		PathFinderHealthCheckPathKey:               "/healthz",
+		PathFinderHealthCheckIntervalKey:           "5s",
+		PathFinderHealthCheckUnhealthyThresholdKey: "2",
+	}}}
+	hc, err := svcHealthCheck(svc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hc.Type != v1.HealthCheckHTTP || hc.Path != "/healthz" || hc.Interval.Duration != 5*time.Second ||
+		hc.UnhealthyThreshold != 2 || hc.HealthyThreshold != defaultHealthyThreshold {
+		t.Fatalf("Unexpected health check %+v", hc)
+	}
+
+	for _, key := range []string{PathFinderHealthCheckIntervalKey, PathFinderHealthCheckTimeoutKey} {
+		for _, v := range []string{"0s", "-1s"} {
+			svc.Annotations[key] = v
+			if _, err := svcHealthCheck(svc); err == nil {
+				t.Fatalf("Expecting error for %s of %s", key, v)
+			}
+			if errs := validateService(corev1.Service{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+				PathFinderAnnotationKey: PathFinderActivated,
+				key:                     v,
+			}}}); len(errs) != 1 {
+				t.Fatalf("Expecting %s of %s rejected, got %v", key, v, errs)
+			}
+		}
+		delete(svc.Annotations, key)
+	}
+
+	svc.Annotations[PathFinderHealthCheckKey] = "UDP"
+	if _, err := svcHealthCheck(svc); err == nil {
+		t.Fatal("Expecting error for unknown health check type")
+	}
+
+	delete(svc.Annotations, PathFinderHealthCheckKey)
+	if hc, err := svcHealthCheck(svc); hc != nil || err != nil {
+		t.Fatal("Expecting health check disabled")
+	}
+}
+
+func TestCountReadyEndpoints(t *testing.T) {
+	http, grpc := "http", "grpc"
+	ready, notReady := true, false
+	slices := []discoveryv1beta1.EndpointSlice{
+		{
+			Ports: []discoveryv1beta1.EndpointPort{{Name: &http}, {Name: &grpc}},
+			Endpoints: []discoveryv1beta1.Endpoint{
+				{Conditions: discoveryv1beta1.EndpointConditions{Ready: &ready}},
+				{Conditions: discoveryv1beta1.EndpointConditions{Ready: &notReady}},
+				{},
+			},
+		},
+		{
+			Ports:     []discoveryv1beta1.EndpointPort{{Name: &http}},
+			Endpoints: []discoveryv1beta1.Endpoint{{}},
+		},
+	}
+	counts := countReadyEndpoints(slices)
+	if counts["http"] != 3 || counts["grpc"] != 2 {
+		t.Fatalf("Unexpected ready endpoints %v", counts)
+	}
+}
+
+func TestDrainEntries(t *testing.T) {
+	now := metav1.Now()
+	old := []v1.ServiceEntry{
+		{ServiceName: "kept"},
+		{ServiceName: "deactivated"},
+		{ServiceName: "expired", State: v1.EntryDraining, DrainingSince: &metav1.Time{Time: now.Add(-time.Minute)}},
+	}
+	entries := []v1.ServiceEntry{{ServiceName: "kept"}}
+
+	reasons := map[string]string{"deactivated": v1.ReasonDeactivated}
+	draining, removed := drainEntries(old, entries, reasons, now, 30*time.Second)
+	if len(draining) != 1 || draining[0].ServiceName != "deactivated" || !draining[0].IsDraining() {
+		t.Fatalf("Unexpected draining entries %v", draining)
+	}
+	if draining[0].Reason != v1.ReasonDeactivated {
+		t.Fatalf("Unexpected draining reason %s", draining[0].Reason)
+	}
+	if len(removed) != 1 || removed[0].ServiceName != "expired" {
+		t.Fatalf("Unexpected removed entries %v", removed)
+	}
+}
+
+func TestBuryEntries(t *testing.T) {
+	now := metav1.Now()
+	tombstones := []v1.Tombstone{
+		{ServiceName: "recent", RemovedAt: metav1.Time{Time: now.Add(-time.Minute)}},
+		{ServiceName: "old", RemovedAt: metav1.Time{Time: now.Add(-time.Hour)}},
+		{ServiceName: "back", RemovedAt: metav1.Time{Time: now.Add(-time.Minute)}},
+	}
+	removed := []v1.ServiceEntry{{ServiceName: "gone", Reason: v1.ReasonServiceDeleted}}
+	entries := []v1.ServiceEntry{{ServiceName: "back"}}
+
+	buried := buryEntries(tombstones, removed, entries, now, 10*time.Minute)
+	if len(buried) != 2 || buried[0].ServiceName != "recent" || buried[1].ServiceName != "gone" {
+		t.Fatalf("Unexpected tombstones %v", buried)
+	}
+	if buried[1].Reason != v1.ReasonServiceDeleted {
+		t.Fatalf("Unexpected tombstone reason %s", buried[1].Reason)
+	}
+}
+
+func TestPanicking(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	r := &PathFinderReconciler{Log: ctrl.Log, Recorder: recorder}
+	pf := &v1.PathFinder{Spec: v1.PathFinderSpec{Region: "DEFAULT"}}
+	old := []v1.ServiceEntry{{ServiceName: "a"}, {ServiceName: "b"}, {ServiceName: "c"}, {ServiceName: "d"}}
+
+	if r.panicking(pf, old, old[:2]) {
+		t.Fatal("Dropping half of entries should not panic")
+	}
+	if !r.panicking(pf, old, old[:1]) || !meta.IsStatusConditionTrue(pf.Status.Conditions, v1.ConditionDegraded) {
+		t.Fatal("Dropping most entries should panic")
+	}
+	r.panicking(pf, old, old[:1])
+	if len(recorder.Events) != 1 {
+		t.Fatalf("Expecting one event, got %d", len(recorder.Events))
+	}
+
+	pf.Annotations = map[string]string{PathFinderConfirmDeregistrationKey: "true"}
+	if r.panicking(pf, old, old[:1]) || meta.IsStatusConditionTrue(pf.Status.Conditions, v1.ConditionDegraded) {
+		t.Fatal("Confirmed drop should not panic")
+	}
+	if _, ok := pf.Annotations[PathFinderConfirmDeregistrationKey]; ok {
+		t.Fatal("Confirmation should be consumed")
+	}
+}
+
+func TestDeletePanicking(t *testing.T) {
+	r := &PathFinderReconciler{Log: ctrl.Log}
+	pf := &v1.PathFinder{Spec: v1.PathFinderSpec{Region: "DEFAULT"}}
+	old := []v1.ServiceEntry{{ServiceName: "a"}, {ServiceName: "b"}, {ServiceName: "c"}, {ServiceName: "d"}}
+	pf.Status.ServiceEntries = old
+	if err := pf.ValidateDelete(); err == nil {
+		t.Fatal("Expecting pathfinder with active entries protected")
+	}
+
+	// All services are gone, as when their namespace is deleted, and entries are held
+	if !r.panicking(pf, old, nil) {
+		t.Fatal("Dropping all entries should panic")
+	}
+	if err := pf.ValidateDelete(); err != nil {
+		t.Fatalf("Entries held by panic threshold should not block deletion, got %v", err)
+	}
+}
+
+func TestDampFlaps(t *testing.T) {
+	r := &PathFinderReconciler{Log: ctrl.Log, FlapThreshold: 3}
+	pf := &v1.PathFinder{}
+	entry := []v1.ServiceEntry{{ServiceName: "a", ServiceHost: "a.test.svc:80"}}
+	now := metav1.Now()
+
+	published := entry
+	for i, desired := range [][]v1.ServiceEntry{nil, entry, nil} {
+		published = r.dampFlaps(pf, published, desired, now)
+		if i < 2 && len(published) != len(desired) {
+			t.Fatalf("Change %d should be published", i+1)
+		}
+	}
+	f, ok := pf.Status.FindFlap("a")
+	if len(published) != 1 || !ok || !f.IsSuppressed(now.Time) || f.Changes != 3 {
+		t.Fatalf("Flapping entry should be held, got %v %v", published, pf.Status.Flaps)
+	}
+
+	later := metav1.NewTime(now.Add(DefaultFlapSuppression))
+	published = r.dampFlaps(pf, published, nil, later)
+	if len(published) != 0 || len(pf.Status.Flaps) != 0 {
+		t.Fatalf("Stable entry should be released, got %v %v", published, pf.Status.Flaps)
+	}
+}
+
+func TestDampFlapsOfAbsentEntry(t *testing.T) {
+	r := &PathFinderReconciler{Log: ctrl.Log, FlapThreshold: 3}
+	pf := &v1.PathFinder{}
+	entry := []v1.ServiceEntry{{ServiceName: "a", ServiceHost: "a.test.svc:80"}}
+	now := metav1.Now()
+
+	// A new service crash looping, suppression starts while it is not published
+	var published []v1.ServiceEntry
+	for i, desired := range [][]v1.ServiceEntry{entry, nil, entry, nil, entry} {
+		published = r.dampFlaps(pf, published, desired, now)
+		if i < 2 {
+			continue
+		}
+		f, ok := pf.Status.FindFlap("a")
+		if len(published) != 0 || !ok || !f.IsSuppressed(now.Time) || f.Changes != int32(i+1) {
+			t.Fatalf("Change %d should be held, got %v %v", i+1, published, pf.Status.Flaps)
+		}
+	}
+}
+
+func TestPausedRebuild(t *testing.T) {
+	r := &PathFinderReconciler{Log: ctrl.Log}
+	pf := &v1.PathFinder{
+		Spec:   v1.PathFinderSpec{Region: "DEFAULT", Paused: true, PauseReason: "incident"},
+		Status: v1.PathFinderStatus{ServiceEntries: []v1.ServiceEntry{{ServiceName: "a"}}},
+	}
+
+	if err := r.RebuildPathfinderRegion(pf, nil); err != nil {
+		t.Fatal(err)
+	}
+	if pf.Status.ServiceEntries[0].IsDraining() || pf.Status.Pending == nil ||
+		len(pf.Status.Pending.Removed) != 1 || pf.Status.Pending.Removed[0] != "a" {
+		t.Fatalf("Paused rebuild should only record pending changes, got %v", pf.Status)
+	}
+
+	pf.Spec.Paused = false
+	if err := r.RebuildPathfinderRegion(pf, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !pf.Status.ServiceEntries[0].IsDraining() || pf.Status.Pending != nil {
+		t.Fatalf("Unpaused rebuild should apply pending changes, got %v", pf.Status)
+	}
+}
+
+func TestStaticEntries(t *testing.T) {
+	r := &PathFinderReconciler{Log: ctrl.Log}
+	pf := &v1.PathFinder{Spec: v1.PathFinderSpec{Region: "DEFAULT", StaticEntries: []v1.StaticEntry{
+		{Name: "db", Host: "fd00::1", Port: 5432},
+		{Name: "hello", Host: "hello.example.com", Port: 80},
+	}}}
+	discovered := []v1.ServiceEntry{{ServiceName: "hello", ServiceHost: "hello.test.svc:80"}}
+
+	entries := r.mergeStaticEntries(pf, discovered)
+	if len(entries) != 2 || entries[0].ServiceHost != "hello.test.svc:80" || entries[0].Static {
+		t.Fatalf("Discovered entry should take precedence, got %v", entries)
+	}
+	if !entries[1].Static || entries[1].ServiceHost != "[fd00::1]:5432" {
+		t.Fatalf("Unexpected static entry %v", entries[1])
+	}
+
+	reasons := make(map[string]string)
+	pf.Spec.StaticEntries = nil
+	staticRemovalReasons(pf, entries, reasons)
+	if len(reasons) != 1 || reasons["db"] != v1.ReasonStaticEntryRemoved {
+		t.Fatalf("Unexpected removal reasons %v", reasons)
+	}
+}
+
+func TestBuildHostFromService(t *testing.T) {
+	svc := corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "hello", Namespace: "test"},
+		Spec:       corev1.ServiceSpec{ClusterIP: "fd00::1", ClusterIPs: []string{"fd00::1", "10.0.0.1"}},
+	}
+	port := corev1.ServicePort{Port: 80}
+
+	cases := map[string]v1.PathFinderSpec{
+		"hello.test.svc:80":               {},
+		"hello.test.svc.cluster.local:80": {ClusterDomain: "cluster.local"},
+		"[fd00::1]:80":                    {HostTemplate: "{{hostport .ClusterIP .Port}}"},
+		"10.0.0.1:80":                     {HostTemplate: "{{hostport .ClusterIPv4 .Port}}"},
+	}
+	for expected, spec := range cases {
+		pf := &v1.PathFinder{Spec: spec}
+		tmpl, err := v1.ParseHostTemplate(spec.EffectiveHostTemplate())
+		if err != nil {
+			t.Fatal(err)
+		}
+		host, err := buildHostFromService(tmpl, pf, svc, port)
+		if err != nil || host != expected {
+			t.Fatalf("Expecting %s, got %s %v", expected, host, err)
+		}
+	}
+}
+
+func TestSvcScheme(t *testing.T) {
+	h2c := "kubernetes.io/h2c"
+	web := corev1.ServicePort{Name: "web", AppProtocol: &h2c}
+	api := corev1.ServicePort{Name: "api"}
+
+	svc := corev1.Service{}
+	if scheme := svcScheme(svc, web); scheme != "h2c" {
+		t.Fatalf("Expecting scheme from app protocol, got %s", scheme)
+	}
+	svc.Annotations = map[string]string{PathFinderSchemeKey: "HTTPS"}
+	if scheme := svcScheme(svc, web); scheme != "https" {
+		t.Fatalf("Expecting scheme from annotation, got %s", scheme)
+	}
+	svc.Annotations[PathFinderSchemeKey] = "web=https, api=grpc"
+	if svcScheme(svc, web) != "https" || svcScheme(svc, api) != "grpc" {
+		t.Fatal("Expecting schemes by port name")
+	}
+}
@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	v1 "github.com/6BD-org/pathfinder/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// conflictingClient fails pathfinder updates, as if health checker wrote status first
+type conflictingClient struct {
+	client.Client
+}
+
+func (c conflictingClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	if pf, ok := obj.(*v1.PathFinder); ok {
+		return apierrors.NewConflict(v1.Resource("pathfinders"), pf.Name, nil)
+	}
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func TestReconcileConflict(t *testing.T) {
+	pf := v1.PathFinder{ObjectMeta: metav1.ObjectMeta{Name: "pf", Namespace: testNamespace}, Spec: v1.PathFinderSpec{Region: "DEFAULT"}}
+	svc := newTestService("orders", "orders", "")
+	svc.Spec.Ports = []corev1.ServicePort{{Name: "http", Port: 80}}
+	r, _ := newTestReconciler(&pf, &svc)
+	req := reconcile.Request{NamespacedName: client.ObjectKey{Namespace: testNamespace, Name: "pf"}}
+
+	res, err := r.Reconcile(context.TODO(), req)
+	if err != nil || res.Requeue {
+		t.Fatalf("Unexpected result %v %v", res, err)
+	}
+	published := v1.PathFinder{}
+	if err := r.Client.Get(context.TODO(), client.ObjectKey{Namespace: testNamespace, Name: "pf"}, &published); err != nil || len(published.Status.ServiceEntries) != 1 {
+		t.Fatalf("Expecting entry of orders published, got %v %v", published.Status.ServiceEntries, err)
+	}
+
+	svc2 := newTestService("billing", "billing", "")
+	svc2.Spec.Ports = []corev1.ServicePort{{Name: "http", Port: 80}}
+	if err := r.Client.Create(context.TODO(), &svc2); err != nil {
+		t.Fatal(err)
+	}
+	r.Client = conflictingClient{Client: r.Client}
+	if res, err := r.Reconcile(context.TODO(), req); err != nil || !res.Requeue || res.RequeueAfter != 0 {
+		t.Fatalf("Expecting conflicting update retried, got %v %v", res, err)
+	}
+}
@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	v1 "github.com/6BD-org/pathfinder/api/v1"
+)
+
+// pendingChanges summarizes differences between published and built entries, nil if there is none.
+// Draining entries are considered removed
+func pendingChanges(published []v1.ServiceEntry, built []v1.ServiceEntry) *v1.PendingChanges {
+	before := make(map[string]v1.ServiceEntry)
+	for _, e := range published {
+		if !e.IsDraining() {
+			before[e.ServiceName] = e
+		}
+	}
+	after := make(map[string]bool)
+
+	pending := v1.PendingChanges{}
+	for _, e := range built {
+		if e.IsDraining() {
+			continue
+		}
+		after[e.ServiceName] = true
+		old, ok := before[e.ServiceName]
+		if !ok {
+			pending.Added = append(pending.Added, e.ServiceName)
+		} else if entrySignature(old, true) != entrySignature(e, true) {
+			pending.Changed = append(pending.Changed, e.ServiceName)
+		}
+	}
+	for _, e := range published {
+		if _, ok := before[e.ServiceName]; ok && !after[e.ServiceName] {
+			pending.Removed = append(pending.Removed, e.ServiceName)
+		}
+	}
+
+	if len(pending.Added) == 0 && len(pending.Removed) == 0 && len(pending.Changed) == 0 {
+		return nil
+	}
+	return &pending
+}
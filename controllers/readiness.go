@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1beta1 "k8s.io/api/discovery/v1beta1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// ReadinessPolicyExclude leaves entries without enough ready endpoints out of pathfinder
+	ReadinessPolicyExclude = "Exclude"
+	// ReadinessPolicyMark keeps entries without enough ready endpoints, marked as NotReady
+	ReadinessPolicyMark = "Mark"
+)
+
+// svcMinReadyEndpoints parses minimum ready endpoints annotation,
+// second returned val is false if readiness gating is disabled
+func svcMinReadyEndpoints(svc corev1.Service) (int32, bool, error) {
+	v, ok := svc.Annotations[PathFinderMinReadyEndpointsKey]
+	if !ok {
+		return 0, false, nil
+	}
+	n, err := strconv.ParseInt(v, 10, 32)
+	if err != nil {
+		return 0, false, err
+	}
+	if n < 0 {
+		return 0, false, fmt.Errorf("Minimum ready endpoints must not be negative, got %d", n)
+	}
+	return int32(n), true, nil
+}
+
+func svcReadinessPolicy(svc corev1.Service) string {
+	if svc.Annotations[PathFinderReadinessPolicyKey] == ReadinessPolicyMark {
+		return ReadinessPolicyMark
+	}
+	return ReadinessPolicyExclude
+}
+
+// ReadyEndpoints counts ready endpoints of a service by port name
+func (r *PathFinderReconciler) ReadyEndpoints(svc corev1.Service) (map[string]int32, error) {
+	slices := discoveryv1beta1.EndpointSliceList{}
+	err := r.Client.List(
+		context.TODO(),
+		&slices,
+		client.InNamespace(svc.Namespace),
+		client.MatchingLabels{discoveryv1beta1.LabelServiceName: svc.Name},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return countReadyEndpoints(slices.Items), nil
+}
+
+func countReadyEndpoints(slices []discoveryv1beta1.EndpointSlice) map[string]int32 {
+	counts := make(map[string]int32)
+	for _, slice := range slices {
+		var ready int32
+		for _, ep := range slice.Endpoints {
+			// nil means ready according to EndpointConditions
+			if ep.Conditions.Ready == nil || *ep.Conditions.Ready {
+				ready++
+			}
+		}
+		for _, p := range slice.Ports {
+			name := ""
+			if p.Name != nil {
+				name = *p.Name
+			}
+			counts[name] += ready
+		}
+	}
+	return counts
+}
+
+// mapEndpointSliceToService triggers reconciling when endpoints of a
+// readiness gated service change, other endpoint slices are ignored
+func (r *PathFinderReconciler) mapEndpointSliceToService(obj client.Object) []reconcile.Request {
+	name, ok := obj.GetLabels()[discoveryv1beta1.LabelServiceName]
+	if !ok {
+		return nil
+	}
+	key := types.NamespacedName{Namespace: obj.GetNamespace(), Name: name}
+	svc := corev1.Service{}
+	if err := r.Client.Get(context.TODO(), key, &svc); err != nil {
+		return nil
+	}
+	if _, gated := svc.Annotations[PathFinderMinReadyEndpointsKey]; !gated {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: key}}
+}
@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	v1 "github.com/6BD-org/pathfinder/api/v1"
+	"github.com/6BD-org/pathfinder/consts"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// leaseReader reads leases without cache, caching leases would watch all leases of the cluster
+func (r *PathFinderReconciler) leaseReader() client.Reader {
+	if r.APIReader != nil {
+		return r.APIReader
+	}
+	return r.Client
+}
+
+// ensureRegionLease makes pf own lease of its region, so that lease is collected with pf.
+// Leases are created for pathfinders created before region leases
+func (r *PathFinderReconciler) ensureRegionLease(pf *v1.PathFinder) error {
+	lease := coordinationv1.Lease{}
+	key := client.ObjectKey{Namespace: pf.Namespace, Name: v1.RegionLeaseName(pf.Spec.Region)}
+	err := r.leaseReader().Get(context.TODO(), key, &lease)
+	if apierrors.IsNotFound(err) {
+		lease = *v1.NewRegionLease(pf, time.Now())
+		if err := controllerutil.SetOwnerReference(pf, &lease, r.Scheme); err != nil {
+			return err
+		}
+		return r.Client.Create(context.TODO(), &lease)
+	}
+	if err != nil {
+		return err
+	}
+
+	if v1.LeaseHolder(&lease) != pf.Name {
+		return nil
+	}
+	for _, ref := range lease.OwnerReferences {
+		if ref.UID == pf.UID {
+			return nil
+		}
+	}
+	if err := controllerutil.SetOwnerReference(pf, &lease, r.Scheme); err != nil {
+		return err
+	}
+	return r.Client.Update(context.TODO(), &lease)
+}
+
+// releaseStaleLeases deletes region leases left behind by pathfinders whose create was rejected
+// after their region was claimed. Leases without owner are stale once the claim grace period
+// passes, if their holder doesn't exist or serves another region
+func (r *PathFinderReconciler) releaseStaleLeases(namespace string, pfs []v1.PathFinder, now time.Time) {
+	leases := coordinationv1.LeaseList{}
+	if err := r.leaseReader().List(context.TODO(), &leases, client.InNamespace(namespace)); err != nil {
+		r.Log.Error(err, consts.ERR_RELEASE_REGION_LEASE, "namespace", namespace)
+		return
+	}
+	for i := range leases.Items {
+		lease := &leases.Items[i]
+		if !strings.HasPrefix(lease.Name, v1.RegionLeasePrefix) || len(lease.OwnerReferences) > 0 {
+			continue
+		}
+		if lease.Spec.AcquireTime != nil && now.Sub(lease.Spec.AcquireTime.Time) < v1.RegionClaimGracePeriod {
+			continue
+		}
+		if holdsLease(pfs, lease) {
+			continue
+		}
+		err := r.Client.Delete(context.TODO(), lease, client.Preconditions{UID: &lease.UID, ResourceVersion: &lease.ResourceVersion})
+		if err != nil && !apierrors.IsNotFound(err) && !apierrors.IsConflict(err) {
+			r.Log.Error(err, consts.ERR_RELEASE_REGION_LEASE, "namespace", namespace, "lease", lease.Name)
+		}
+	}
+}
+
+// holdsLease tells if holder of lease is a pathfinder of the region of lease
+func holdsLease(pfs []v1.PathFinder, lease *coordinationv1.Lease) bool {
+	holder := v1.LeaseHolder(lease)
+	for _, pf := range pfs {
+		if pf.Name == holder && v1.RegionLeaseName(pf.Spec.Region) == lease.Name {
+			return true
+		}
+	}
+	return false
+}
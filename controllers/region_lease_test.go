@@ -0,0 +1,43 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "github.com/6BD-org/pathfinder/api/v1"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestReleaseStaleLeases(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-v1.RegionClaimGracePeriod)
+	east := v1.PathFinder{ObjectMeta: metav1.ObjectMeta{Name: "east", Namespace: testNamespace}, Spec: v1.PathFinderSpec{Region: "EAST"}}
+	lease := func(holder string, region string, acquired time.Time) *coordinationv1.Lease {
+		pf := v1.PathFinder{ObjectMeta: metav1.ObjectMeta{Name: holder, Namespace: testNamespace}, Spec: v1.PathFinderSpec{Region: region}}
+		return v1.NewRegionLease(&pf, acquired)
+	}
+	other := coordinationv1.Lease{ObjectMeta: metav1.ObjectMeta{Name: "leader", Namespace: testNamespace}}
+
+	r, _ := newTestReconciler(&east,
+		lease("east", "EAST", past),
+		// Rejected creates, of a pathfinder never created and one named after an existing pathfinder
+		lease("ghost", "WEST", past),
+		lease("east", "NORTH", past),
+		// Being claimed
+		lease("new", "SOUTH", now),
+		&other)
+	r.releaseStaleLeases(testNamespace, []v1.PathFinder{east}, now)
+
+	leases := coordinationv1.LeaseList{}
+	_ = r.Client.List(context.TODO(), &leases, client.InNamespace(testNamespace))
+	kept := make(map[string]bool)
+	for _, l := range leases.Items {
+		kept[l.Name] = true
+	}
+	if len(kept) != 3 || !kept[v1.RegionLeaseName("EAST")] || !kept[v1.RegionLeaseName("SOUTH")] || !kept["leader"] {
+		t.Fatalf("Unexpected leases %v", kept)
+	}
+}
@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "github.com/6BD-org/pathfinder/api/v1"
+	"github.com/6BD-org/pathfinder/consts"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DefaultRegistrationResync is used when reconciler has no registration resync configured
+const DefaultRegistrationResync = 10 * time.Minute
+
+func (r *PathFinderReconciler) registrationResync() time.Duration {
+	if r.RegistrationResync > 0 {
+		return r.RegistrationResync
+	}
+	return DefaultRegistrationResync
+}
+
+// reportRegistrations writes registration of annotated services back onto them. pfs are
+// pathfinders as published by region, services of other regions report region not found
+func (r *PathFinderReconciler) reportRegistrations(svcs []corev1.Service, pfs map[string]*v1.PathFinder) {
+	now := time.Now()
+	collisions := registrationCollisions(svcs)
+	for i := range svcs {
+		svc := &svcs[i]
+		if _, ok := svc.Annotations[PathFinderAnnotationKey]; !ok {
+			if _, ok := svc.Annotations[v1.RegistrationAnnotation]; ok {
+				patch := client.MergeFrom(svc.DeepCopy())
+				delete(svc.Annotations, v1.RegistrationAnnotation)
+				r.patchRegistration(svc, patch)
+			}
+			continue
+		}
+
+		reg := registration(*svc, pfs, collisions)
+		old, err := v1.ParseRegistration(svc.Annotations)
+		if err == nil && old != nil && old.SameAs(&reg) && now.Sub(old.LastSyncTime.Time) < r.registrationResync() {
+			continue
+		}
+		reg.LastSyncTime = metav1.NewTime(now)
+		raw, err := json.Marshal(reg)
+		if err != nil {
+			continue
+		}
+		patch := client.MergeFrom(svc.DeepCopy())
+		svc.Annotations[v1.RegistrationAnnotation] = string(raw)
+		r.patchRegistration(svc, patch)
+	}
+}
+
+func (r *PathFinderReconciler) patchRegistration(svc *corev1.Service, patch client.Patch) {
+	if err := r.Client.Patch(context.TODO(), svc, patch); err != nil {
+		r.Log.Error(err, consts.ERR_UPDATE_FAIL, "namespace", svc.Namespace, "svc", svc.Name)
+	}
+}
+
+// registration builds registration of a service, sync time is left for caller
+func registration(svc corev1.Service, pfs map[string]*v1.PathFinder, collisions map[string][]*corev1.Service) v1.Registration {
+	region, ok := svcRegion(svc)
+	if !ok {
+		region = PathFinderDefaultRegion
+	}
+	reg := v1.Registration{Region: v1.NormalizeRegion(region)}
+
+	if errs := v1.ValidateRegion(region, field.NewPath("metadata", "annotations").Key(PathFinderRegionKey)); len(errs) > 0 {
+		reg.Errors = append(reg.Errors, fmt.Sprintf(consts.F_ERR_REGISTRATION_INVALID_REGION, region))
+		return reg
+	}
+	pf, ok := pfs[reg.Region]
+	if !ok {
+		reg.Errors = append(reg.Errors, fmt.Sprintf(consts.F_ERR_REGISTRATION_REGION_NOT_FOUND, reg.Region))
+		return reg
+	}
+	if _, ok := svcRegistractionName(svc); !ok || !svcPathFinderEnabled(svc) {
+		return reg
+	}
+	names := make([]string, 0)
+	for name := range entryNames(svc) {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	colliding := make(map[string]bool)
+	for _, name := range names {
+		if others := collidingServices(collisions, reg.Region, name, svc.Name); len(others) > 0 {
+			reg.Errors = append(reg.Errors, fmt.Sprintf(consts.F_ERR_REGISTRATION_NAME_COLLISION, name, strings.Join(others, ",")))
+			colliding[name] = true
+		}
+	}
+	// Entries belong to services publishing them, merged entries also to services whose hosts are merged in
+	merged := pf.Spec.EffectiveCollisionPolicy() == v1.CollisionMergeHosts
+	for _, entry := range pf.Status.ServiceEntries {
+		if entry.Static || entry.IsDraining() {
+			continue
+		}
+		if entry.KubeServiceName == svc.Name || (merged && colliding[entry.ServiceName]) {
+			reg.Entries = append(reg.Entries, entry.ServiceName)
+		}
+	}
+	sort.Strings(reg.Entries)
+	return reg
+}
+
+// registrationCollisions groups enabled services by region and names of entries they register
+func registrationCollisions(svcs []corev1.Service) map[string][]*corev1.Service {
+	collisions := make(map[string][]*corev1.Service)
+	for i := range svcs {
+		svc := svcs[i]
+		if !svcPathFinderEnabled(svc) {
+			continue
+		}
+		region, ok := svcRegion(svc)
+		if !ok {
+			region = PathFinderDefaultRegion
+		}
+		for name := range entryNames(svc) {
+			key := registrationKey(region, name)
+			collisions[key] = append(collisions[key], &svcs[i])
+		}
+	}
+	return collisions
+}
+
+// collidingServices returns names of services other than svcName registering entry of name in region
+func collidingServices(collisions map[string][]*corev1.Service, region string, name string, svcName string) []string {
+	others := []string{}
+	for _, other := range collisions[registrationKey(region, name)] {
+		if other.Name != svcName {
+			others = append(others, other.Name)
+		}
+	}
+	sort.Strings(others)
+	return others
+}
+
+func registrationKey(region string, name string) string {
+	return v1.NormalizeRegion(region) + "/" + name
+}
@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	v1 "github.com/6BD-org/pathfinder/api/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestRegistration(t *testing.T) {
+	pfs := map[string]*v1.PathFinder{
+		"EAST": {Status: v1.PathFinderStatus{ServiceEntries: []v1.ServiceEntry{
+			{ServiceName: "orders/http", KubeServiceName: "orders"},
+			{ServiceName: "orders/grpc", KubeServiceName: "orders"},
+			{ServiceName: "orders/web", KubeServiceName: "orders-v2"},
+			{ServiceName: "orders-db", Static: true},
+			{ServiceName: "orders/admin", KubeServiceName: "orders", State: v1.EntryDraining},
+		}}},
+	}
+	withPorts := func(svc corev1.Service, names ...string) corev1.Service {
+		for _, name := range names {
+			svc.Spec.Ports = append(svc.Spec.Ports, corev1.ServicePort{Name: name})
+		}
+		return svc
+	}
+	svcs := []corev1.Service{
+		withPorts(newTestService("orders", "orders", "east"), "http", "grpc"),
+		withPorts(newTestService("orders-v2", "orders", "EAST"), "http", "web"),
+		withPorts(newTestService("billing", "billing", "WEST"), "http"),
+		withPorts(newTestService("broken", "broken", "east_1"), "http"),
+	}
+	collisions := registrationCollisions(svcs)
+
+	reg := registration(svcs[0], pfs, collisions)
+	if reg.Region != "EAST" || len(reg.Entries) != 2 || reg.Entries[0] != "orders/grpc" || reg.Entries[1] != "orders/http" {
+		t.Fatalf("Unexpected registration %v", reg)
+	}
+	if len(reg.Errors) != 1 || !strings.Contains(reg.Errors[0], "orders/http") || !strings.Contains(reg.Errors[0], "orders-v2") {
+		t.Fatalf("Expecting collision of orders/http, got %v", reg.Errors)
+	}
+	// Entries of other services with the same registration name are not attributed
+	reg = registration(svcs[1], pfs, collisions)
+	if len(reg.Entries) != 1 || reg.Entries[0] != "orders/web" || len(reg.Errors) != 1 {
+		t.Fatalf("Expecting only entries published for the service, got %v", reg)
+	}
+	pfs["EAST"].Spec.CollisionPolicy = v1.CollisionMergeHosts
+	if reg := registration(svcs[1], pfs, collisions); len(reg.Entries) != 2 || reg.Entries[0] != "orders/http" {
+		t.Fatalf("Expecting merged entries attributed, got %v", reg)
+	}
+
+	if reg := registration(svcs[2], pfs, collisions); len(reg.Errors) != 1 || len(reg.Entries) != 0 {
+		t.Fatalf("Expecting region not found, got %v", reg)
+	}
+	if reg := registration(svcs[3], pfs, collisions); len(reg.Errors) != 1 || reg.Region != "EAST_1" {
+		t.Fatalf("Expecting invalid region, got %v", reg)
+	}
+}
@@ -0,0 +1,316 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	v1 "github.com/6BD-org/pathfinder/api/v1"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	// MutateServicePath and ValidateServicePath are paths of service webhooks, see markers below
+	MutateServicePath   = "/mutate-v1-service"
+	ValidateServicePath = "/validate-v1-service"
+
+	// pathfinderAnnotationPrefix is shared by all annotations of pathfinder
+	pathfinderAnnotationPrefix = "XM-PathFinder-"
+)
+
+// knownServiceAnnotations are annotations of pathfinder on services, others with
+// the same prefix are likely typos
+var knownServiceAnnotations = map[string]bool{
+	PathFinderAnnotationKey:                    true,
+	PathFinderRegionKey:                        true,
+	PathFinderServiceRegistrationNameKey:       true,
+	PathFinderHealthCheckKey:                   true,
+	PathFinderHealthCheckPathKey:               true,
+	PathFinderHealthCheckIntervalKey:           true,
+	PathFinderHealthCheckTimeoutKey:            true,
+	PathFinderHealthCheckHealthyThresholdKey:   true,
+	PathFinderHealthCheckUnhealthyThresholdKey: true,
+	PathFinderMinReadyEndpointsKey:             true,
+	PathFinderReadinessPolicyKey:               true,
+	PathFinderSchemeKey:                        true,
+	PathFinderOrphanedKey:                      true,
+	v1.RegistrationAnnotation:                  true,
+}
+
+// controllerAnnotations are written by controller, updates only changing them are not validated
+var controllerAnnotations = map[string]bool{
+	PathFinderOrphanedKey:     true,
+	v1.RegistrationAnnotation: true,
+}
+
+// Services are admitted if webhook is down, a failing pathfinder must not block all services
+// +kubebuilder:webhook:path=/mutate-v1-service,mutating=true,failurePolicy=ignore,groups="",resources=services,verbs=create;update,versions=v1,name=mservice.kb.io
+// +kubebuilder:webhook:path=/validate-v1-service,mutating=false,failurePolicy=ignore,groups="",resources=services,verbs=create;update,versions=v1,name=vservice.kb.io
+
+// SetupServiceWebhookWithManager registers service webhooks on webhook server of manager
+func SetupServiceWebhookWithManager(mgr ctrl.Manager) {
+	server := mgr.GetWebhookServer()
+	server.Register(MutateServicePath, &webhook.Admission{Handler: &ServiceDefaulter{}})
+	server.Register(ValidateServicePath, &webhook.Admission{Handler: &ServiceValidator{Client: mgr.GetClient()}})
+}
+
+// ServiceDefaulter defaults registration name of services to their name
+type ServiceDefaulter struct {
+	decoder *admission.Decoder
+}
+
+// Handle implements admission.Handler
+func (d *ServiceDefaulter) Handle(ctx context.Context, req admission.Request) admission.Response {
+	svc := corev1.Service{}
+	if err := d.decoder.Decode(req, &svc); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if !defaultService(&svc) {
+		return admission.Allowed("")
+	}
+	raw, err := json.Marshal(svc)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, raw)
+}
+
+// InjectDecoder implements admission.DecoderInjector
+func (d *ServiceDefaulter) InjectDecoder(decoder *admission.Decoder) error {
+	d.decoder = decoder
+	return nil
+}
+
+// defaultService sets registration name of pathfinder services without one, false if nothing changed
+func defaultService(svc *corev1.Service) bool {
+	if _, ok := svc.Annotations[PathFinderAnnotationKey]; !ok {
+		return false
+	}
+	if _, ok := svcRegistractionName(*svc); ok {
+		return false
+	}
+	svc.Annotations[PathFinderServiceRegistrationNameKey] = svc.Name
+	return true
+}
+
+// ServiceValidator rejects services with invalid pathfinder annotations, and warns about
+// annotations likely to be typos and regions without pathfinder
+type ServiceValidator struct {
+	Client  client.Reader
+	decoder *admission.Decoder
+}
+
+// Handle implements admission.Handler
+func (v *ServiceValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	svc := corev1.Service{}
+	if err := v.decoder.Decode(req, &svc); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if _, ok := svc.Annotations[PathFinderAnnotationKey]; !ok {
+		return admission.Allowed("")
+	}
+	// Updates are checked for what they change, annotations and registered entries separately
+	validate, register := true, true
+	if req.Operation == admissionv1.Update {
+		old := corev1.Service{}
+		if err := v.decoder.DecodeRaw(req.OldObject, &old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		validate, register = pathfinderAnnotationsChanged(old, svc), registrationChanged(old, svc)
+		if !validate && !register {
+			return admission.Allowed("")
+		}
+	}
+	if errs := validateService(svc); validate && len(errs) > 0 {
+		return admission.Denied(errs.ToAggregate().Error())
+	}
+
+	warnings := serviceAnnotationWarnings(svc)
+	region, ok := svcRegion(svc)
+	if !ok {
+		region = PathFinderDefaultRegion
+	}
+	pfl := v1.PathFinderList{}
+	if err := v.Client.List(ctx, &pfl, client.InNamespace(req.Namespace)); err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	pf := liveRegion(pfl.Items, region)
+	if pf == nil {
+		warnings = append(warnings, fmt.Sprintf("no pathfinder of region %s in namespace %s, service is not discoverable until one is created", region, req.Namespace))
+		return admission.Allowed("").WithWarnings(warnings...)
+	}
+
+	if pf.Spec.EffectiveCollisionPolicy() == v1.CollisionRejectNewest && register && svcPathFinderEnabled(svc) {
+		svcs := corev1.ServiceList{}
+		if err := v.Client.List(ctx, &svcs, client.InNamespace(req.Namespace)); err != nil {
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+		if others := olderCollisions(svc, svcs.Items); len(others) > 0 {
+			return admission.Denied(fmt.Sprintf("entries of %s are registered by older services %s of region %s, which rejects newest on collision",
+				svc.Annotations[PathFinderServiceRegistrationNameKey], strings.Join(others, ","), pf.Spec.Region))
+		}
+	}
+	return admission.Allowed("").WithWarnings(warnings...)
+}
+
+// InjectDecoder implements admission.DecoderInjector
+func (v *ServiceValidator) InjectDecoder(decoder *admission.Decoder) error {
+	v.decoder = decoder
+	return nil
+}
+
+// olderCollisions returns enabled services older than svc, registering entries of the same names
+// in region of svc. Services being created have no creation timestamp, and are the newest
+func olderCollisions(svc corev1.Service, svcs []corev1.Service) []string {
+	region, _ := svcRegion(svc)
+	names := entryNames(svc)
+	others := []string{}
+	for i := range svcs {
+		other := &svcs[i]
+		if other.Name == svc.Name || !svcPathFinderEnabled(*other) {
+			continue
+		}
+		if otherRegion, _ := svcRegion(*other); !v1.SameRegion(regionOrDefault(otherRegion), regionOrDefault(region)) {
+			continue
+		}
+		if !svc.CreationTimestamp.IsZero() && !olderService(other, &svc) {
+			continue
+		}
+		for name := range entryNames(*other) {
+			if names[name] {
+				others = append(others, other.Name)
+				break
+			}
+		}
+	}
+	sort.Strings(others)
+	return others
+}
+
+// entryNames returns names of entries a service registers
+func entryNames(svc corev1.Service) map[string]bool {
+	names := make(map[string]bool)
+	name, ok := svcRegistractionName(svc)
+	if !ok {
+		return names
+	}
+	for _, p := range svc.Spec.Ports {
+		names[formatServiceName(name, p.Name)] = true
+	}
+	return names
+}
+
+// pathfinderAnnotationsChanged tells if pathfinder annotations other than ones written by
+// controller differ between old and svc
+func pathfinderAnnotationsChanged(old corev1.Service, svc corev1.Service) bool {
+	userAnnotation := func(k string) bool {
+		return strings.HasPrefix(strings.ToLower(k), strings.ToLower(pathfinderAnnotationPrefix)) && !controllerAnnotations[k]
+	}
+	count := 0
+	for k, v := range svc.Annotations {
+		if !userAnnotation(k) {
+			continue
+		}
+		count++
+		if ov, ok := old.Annotations[k]; !ok || ov != v {
+			return true
+		}
+	}
+	for k := range old.Annotations {
+		if userAnnotation(k) {
+			count--
+		}
+	}
+	return count != 0
+}
+
+// registrationChanged tells if svc registers different entries than old, as registration
+// name, port names, region or enablement changes
+func registrationChanged(old corev1.Service, svc corev1.Service) bool {
+	if svcPathFinderEnabled(old) != svcPathFinderEnabled(svc) {
+		return true
+	}
+	oldRegion, _ := svcRegion(old)
+	region, _ := svcRegion(svc)
+	if !v1.SameRegion(regionOrDefault(oldRegion), regionOrDefault(region)) {
+		return true
+	}
+	oldNames, names := entryNames(old), entryNames(svc)
+	if len(oldNames) != len(names) {
+		return true
+	}
+	for name := range names {
+		if !oldNames[name] {
+			return true
+		}
+	}
+	return false
+}
+
+func regionOrDefault(region string) string {
+	if len(region) == 0 {
+		return PathFinderDefaultRegion
+	}
+	return region
+}
+
+// validateService validates pathfinder annotations of a service
+func validateService(svc corev1.Service) field.ErrorList {
+	annotationsPath := field.NewPath("metadata", "annotations")
+	errs := field.ErrorList{}
+
+	if p := svc.Annotations[PathFinderAnnotationKey]; p != PathFinderActivated && p != PathFinderDeactiveted {
+		errs = append(errs, field.NotSupported(annotationsPath.Key(PathFinderAnnotationKey), p,
+			[]string{PathFinderActivated, PathFinderDeactiveted}))
+	}
+	if name, ok := svcRegistractionName(svc); ok {
+		for _, msg := range validation.IsDNS1123Subdomain(name) {
+			errs = append(errs, field.Invalid(annotationsPath.Key(PathFinderServiceRegistrationNameKey), name, msg))
+		}
+	}
+	if region, ok := svcRegion(svc); ok {
+		errs = append(errs, v1.ValidateRegion(region, annotationsPath.Key(PathFinderRegionKey))...)
+	}
+	durationErrs := field.ErrorList{}
+	for _, key := range []string{PathFinderHealthCheckIntervalKey, PathFinderHealthCheckTimeoutKey} {
+		if v, ok := svc.Annotations[key]; ok {
+			if _, err := parsePositiveDuration(v); err != nil {
+				durationErrs = append(durationErrs, field.Invalid(annotationsPath.Key(key), v, err.Error()))
+			}
+		}
+	}
+	errs = append(errs, durationErrs...)
+	if _, err := svcHealthCheck(svc); err != nil && len(durationErrs) == 0 {
+		errs = append(errs, field.Invalid(annotationsPath.Key(PathFinderHealthCheckKey), svc.Annotations[PathFinderHealthCheckKey], err.Error()))
+	}
+	if _, _, err := svcMinReadyEndpoints(svc); err != nil {
+		errs = append(errs, field.Invalid(annotationsPath.Key(PathFinderMinReadyEndpointsKey), svc.Annotations[PathFinderMinReadyEndpointsKey], err.Error()))
+	}
+	if p, ok := svc.Annotations[PathFinderReadinessPolicyKey]; ok && p != ReadinessPolicyExclude && p != ReadinessPolicyMark {
+		errs = append(errs, field.NotSupported(annotationsPath.Key(PathFinderReadinessPolicyKey), p,
+			[]string{ReadinessPolicyExclude, ReadinessPolicyMark}))
+	}
+	return errs
+}
+
+// serviceAnnotationWarnings warns about unknown annotations with pathfinder prefix
+func serviceAnnotationWarnings(svc corev1.Service) []string {
+	warnings := []string{}
+	for k := range svc.Annotations {
+		if strings.HasPrefix(strings.ToLower(k), strings.ToLower(pathfinderAnnotationPrefix)) && !knownServiceAnnotations[k] {
+			warnings = append(warnings, fmt.Sprintf("unknown annotation %s is ignored by pathfinder", k))
+		}
+	}
+	sort.Strings(warnings)
+	return warnings
+}
@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	v1 "github.com/6BD-org/pathfinder/api/v1"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// newTestValidator builds a service validator reading objs
+func newTestValidator(t *testing.T, objs ...runtime.Object) *ServiceValidator {
+	r, _ := newTestReconciler(objs...)
+	decoder, err := admission.NewDecoder(newTestScheme())
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := &ServiceValidator{Client: r.Client}
+	_ = v.InjectDecoder(decoder)
+	return v
+}
+
+// serviceRequest builds admission request of svc, old is only sent for updates
+func serviceRequest(t *testing.T, op admissionv1.Operation, svc corev1.Service, old *corev1.Service) admission.Request {
+	req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{Operation: op, Namespace: svc.Namespace}}
+	raw, err := json.Marshal(svc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Object = runtime.RawExtension{Raw: raw}
+	if old != nil {
+		if req.OldObject.Raw, err = json.Marshal(old); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return req
+}
+
+func TestServiceWebhook(t *testing.T) {
+	svc := corev1.Service{ObjectMeta: metav1.ObjectMeta{
+		Name:        "orders",
+		Annotations: map[string]string{PathFinderAnnotationKey: PathFinderActivated},
+	}}
+	if !defaultService(&svc) || svc.Annotations[PathFinderServiceRegistrationNameKey] != "orders" {
+		t.Fatalf("Expecting registration name to be defaulted, got %v", svc.Annotations)
+	}
+	if defaultService(&svc) {
+		t.Fatal("Registration name should not be defaulted twice")
+	}
+	if errs := validateService(svc); len(errs) > 0 {
+		t.Fatalf("Unexpected errors %v", errs)
+	}
+
+	svc.Annotations[PathFinderAnnotationKey] = "activated"
+	svc.Annotations[PathFinderServiceRegistrationNameKey] = "Orders/v1"
+	svc.Annotations[PathFinderRegionKey] = "east_1"
+	svc.Annotations[PathFinderHealthCheckKey] = "UDP"
+	if errs := validateService(svc); len(errs) != 4 {
+		t.Fatalf("Expecting 4 errors, got %v", errs)
+	}
+
+	svc.Annotations["XM-PathFinder-Regoin"] = "EAST"
+	if warnings := serviceAnnotationWarnings(svc); len(warnings) != 1 {
+		t.Fatalf("Expecting a warning about typo, got %v", warnings)
+	}
+}
+
+func TestOlderCollisions(t *testing.T) {
+	newSvc := func(name string, created time.Time) corev1.Service {
+		svc := newTestService(name, "orders", "")
+		svc.CreationTimestamp = metav1.NewTime(created)
+		svc.Spec.Ports = []corev1.ServicePort{{Name: "http"}}
+		return svc
+	}
+	existing := []corev1.Service{newSvc("orders", time.Now().Add(-time.Hour)), newSvc("orders-v2", time.Now())}
+
+	created := newSvc("orders-v3", time.Time{})
+	if others := olderCollisions(created, existing); len(others) != 2 {
+		t.Fatalf("Services being created should collide with all, got %v", others)
+	}
+	if others := olderCollisions(existing[0], existing); len(others) != 0 {
+		t.Fatalf("Oldest service should not be rejected, got %v", others)
+	}
+	created.Spec.Ports[0].Name = "grpc"
+	if others := olderCollisions(created, existing); len(others) != 0 {
+		t.Fatalf("Entries of different ports should not collide, got %v", others)
+	}
+}
+
+func TestServiceValidatorUpdate(t *testing.T) {
+	east := v1.PathFinder{ObjectMeta: metav1.ObjectMeta{Name: "east", Namespace: testNamespace}, Spec: v1.PathFinderSpec{Region: "EAST"}}
+	v := newTestValidator(t, &east)
+
+	// Services annotated before the webhook may be invalid already
+	old := newTestService("orders", "orders", "east_1")
+	svc := *old.DeepCopy()
+	svc.Annotations[v1.RegistrationAnnotation] = "{}"
+	svc.Annotations[PathFinderOrphanedKey] = "EAST_1"
+	svc.Labels = map[string]string{"app": "orders"}
+	if resp := v.Handle(context.TODO(), serviceRequest(t, admissionv1.Update, svc, &old)); !resp.Allowed {
+		t.Fatalf("Expecting updates of controller annotations allowed, got %v", resp.Result)
+	}
+	if resp := v.Handle(context.TODO(), serviceRequest(t, admissionv1.Create, svc, nil)); resp.Allowed {
+		t.Fatal("Expecting invalid service rejected on create")
+	}
+
+	svc.Annotations[PathFinderRegionKey] = "east_2"
+	if resp := v.Handle(context.TODO(), serviceRequest(t, admissionv1.Update, svc, &old)); resp.Allowed {
+		t.Fatal("Expecting changed pathfinder annotations validated")
+	}
+	// Removed region falls back to DEFAULT, which has no pathfinder
+	old.Annotations[PathFinderRegionKey] = "east"
+	delete(svc.Annotations, PathFinderRegionKey)
+	if resp := v.Handle(context.TODO(), serviceRequest(t, admissionv1.Update, svc, &old)); !resp.Allowed || len(resp.Warnings) == 0 {
+		t.Fatalf("Expecting removed pathfinder annotations validated, got %v", resp.Warnings)
+	}
+}
+
+func TestServiceValidatorRejectNewest(t *testing.T) {
+	east := v1.PathFinder{
+		ObjectMeta: metav1.ObjectMeta{Name: "east", Namespace: testNamespace},
+		Spec:       v1.PathFinderSpec{Region: "EAST", CollisionPolicy: v1.CollisionRejectNewest},
+	}
+	newSvc := func(name string, created time.Time) corev1.Service {
+		svc := newTestService(name, "orders", "east")
+		svc.CreationTimestamp = metav1.NewTime(created)
+		svc.Spec.Ports = []corev1.ServicePort{{Name: "http"}}
+		return svc
+	}
+	older := newSvc("orders", time.Now().Add(-time.Hour))
+	// Admitted while admission was bypassed
+	newer := newSvc("orders-v2", time.Now())
+	v := newTestValidator(t, &east, &older, &newer)
+
+	created := newSvc("orders-v3", time.Time{})
+	if resp := v.Handle(context.TODO(), serviceRequest(t, admissionv1.Create, created, nil)); resp.Allowed {
+		t.Fatal("Expecting colliding service rejected on create")
+	}
+
+	// Updates not changing registered entries are left alone
+	svc := *newer.DeepCopy()
+	svc.Annotations[PathFinderHealthCheckKey] = "TCP"
+	if resp := v.Handle(context.TODO(), serviceRequest(t, admissionv1.Update, svc, &newer)); !resp.Allowed {
+		t.Fatalf("Expecting update not changing registration allowed, got %v", resp.Result)
+	}
+	svc.Spec.Ports = append(svc.Spec.Ports, corev1.ServicePort{Name: "web"})
+	if resp := v.Handle(context.TODO(), serviceRequest(t, admissionv1.Update, svc, &newer)); resp.Allowed {
+		t.Fatal("Expecting update changing registered entries rejected")
+	}
+	svc = *newer.DeepCopy()
+	svc.Annotations[PathFinderAnnotationKey] = PathFinderDeactiveted
+	if resp := v.Handle(context.TODO(), serviceRequest(t, admissionv1.Update, svc, &newer)); !resp.Allowed {
+		t.Fatalf("Expecting disabling colliding service allowed, got %v", resp.Result)
+	}
+}
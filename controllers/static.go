@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"net"
+	"strconv"
+
+	v1 "github.com/6BD-org/pathfinder/api/v1"
+	"github.com/6BD-org/pathfinder/consts"
+)
+
+func buildStaticEntry(static v1.StaticEntry) v1.ServiceEntry {
+	payload := static.Payload
+	if payload.KeyValPairs == nil {
+		payload.KeyValPairs = make([]v1.PayloadKeyValPair, 0)
+	}
+	return v1.ServiceEntry{
+		ServiceName: static.Name,
+		ServiceHost: net.JoinHostPort(static.Host, strconv.Itoa(int(static.Port))),
+		Payload:     payload,
+		Static:      true,
+	}
+}
+
+// mergeStaticEntries appends static entries of pf to discovered entries. Discovered entries
+// take precedence, static entries of the same name are ignored
+func (r *PathFinderReconciler) mergeStaticEntries(pf *v1.PathFinder, entries []v1.ServiceEntry) []v1.ServiceEntry {
+	discovered := make(map[string]bool)
+	for _, e := range entries {
+		discovered[e.ServiceName] = true
+	}
+	for _, static := range pf.Spec.StaticEntries {
+		if discovered[static.Name] {
+			r.Log.Info(consts.WARN_STATIC_ENTRY_SHADOWED, "namespace", pf.Namespace, "region", pf.Spec.Region, "entry", static.Name)
+			continue
+		}
+		discovered[static.Name] = true
+		entries = append(entries, buildStaticEntry(static))
+	}
+	return entries
+}
+
+// staticRemovalReasons marks static entries removed from pf spec
+func staticRemovalReasons(pf *v1.PathFinder, oldEntries []v1.ServiceEntry, reasons map[string]string) {
+	declared := make(map[string]bool)
+	for _, static := range pf.Spec.StaticEntries {
+		declared[static.Name] = true
+	}
+	for _, old := range oldEntries {
+		if old.Static && !declared[old.ServiceName] {
+			reasons[old.ServiceName] = v1.ReasonStaticEntryRemoved
+		}
+	}
+}
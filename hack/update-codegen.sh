@@ -0,0 +1,61 @@
+#!/usr/bin/env bash
+
+# Generates typed clientset, listers and informers for api/v1 under pkg/generated.
+#
+# Generators of k8s.io/code-generator work on GOPATH layout and take group name
+# from package path, where api/v1 would be taken as the legacy core group.
+# So the repo is mirrored into a temporary GOPATH, with api/v1 also exposed
+# as apis/xmbsmdsj/v1, and imports are pointed back to api/v1 after generation.
+
+set -o errexit
+set -o nounset
+set -o pipefail
+
+MODULE=github.com/6BD-org/pathfinder
+APIS=${MODULE}/apis/xmbsmdsj/v1
+OUTPUT=${MODULE}/pkg/generated
+BOILERPLATE=hack/boilerplate.go.txt
+
+ROOT=$(cd "$(dirname "${BASH_SOURCE[0]}")/.." && pwd)
+GOBIN=${GOBIN:-$(go env GOPATH)/bin}
+TMP_GOPATH=$(mktemp -d)
+trap 'rm -rf "${TMP_GOPATH}"' EXIT
+
+MIRROR=${TMP_GOPATH}/src/${MODULE}
+mkdir -p "${MIRROR}/apis/xmbsmdsj"
+for f in "${ROOT}"/*; do
+	ln -s "${f}" "${MIRROR}/$(basename "${f}")"
+done
+ln -s "${ROOT}/api/v1" "${MIRROR}/apis/xmbsmdsj/v1"
+cd "${MIRROR}"
+
+export GOPATH=${TMP_GOPATH}
+export GO111MODULE=off
+OUTPUT_BASE=${TMP_GOPATH}/out
+
+"${GOBIN}/client-gen" \
+	--clientset-name versioned \
+	--input-base "" \
+	--input "${APIS}" \
+	--output-package "${OUTPUT}/clientset" \
+	--output-base "${OUTPUT_BASE}" \
+	--go-header-file "${BOILERPLATE}"
+
+"${GOBIN}/lister-gen" \
+	--input-dirs "${APIS}" \
+	--output-package "${OUTPUT}/listers" \
+	--output-base "${OUTPUT_BASE}" \
+	--go-header-file "${BOILERPLATE}"
+
+"${GOBIN}/informer-gen" \
+	--input-dirs "${APIS}" \
+	--versioned-clientset-package "${OUTPUT}/clientset/versioned" \
+	--listers-package "${OUTPUT}/listers" \
+	--output-package "${OUTPUT}/informers" \
+	--output-base "${OUTPUT_BASE}" \
+	--go-header-file "${BOILERPLATE}"
+
+grep -rl "${APIS}" "${OUTPUT_BASE}/${OUTPUT}" | xargs sed -i "s|${APIS}|${MODULE}/api/v1|g"
+rm -rf "${ROOT}/pkg/generated"
+mkdir -p "${ROOT}/pkg"
+cp -r "${OUTPUT_BASE}/${OUTPUT}" "${ROOT}/pkg/generated"
@@ -19,6 +19,7 @@ package main
 import (
 	"flag"
 	"os"
+	"time"
 
 	"k8s.io/apimachinery/pkg/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -46,10 +47,40 @@ func init() {
 func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
+	var drainGracePeriod time.Duration
+	var tombstoneRetention time.Duration
+	var panicThreshold int
+	var panicMinEntries int
+	var flapThreshold int
+	var flapWindow time.Duration
+	var flapSuppression time.Duration
+	var registrationResync time.Duration
+	var autoProvisionRegionLimit int
+	var clusterDomain string
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8380", "The address the metric endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.DurationVar(&drainGracePeriod, "drain-grace-period", controllers.DefaultDrainGracePeriod,
+		"How long entries of deactivated or deleted services stay draining before removed.")
+	flag.DurationVar(&tombstoneRetention, "tombstone-retention", controllers.DefaultTombstoneRetention,
+		"How long tombstones of removed entries are kept in pathfinder status.")
+	flag.IntVar(&panicThreshold, "panic-threshold", controllers.DefaultPanicThreshold,
+		"Percentage of entries of a region allowed to be dropped in one reconcile without confirmation, 100 disables it.")
+	flag.IntVar(&panicMinEntries, "panic-min-entries", controllers.DefaultPanicMinEntries,
+		"Regions with less entries than this are not protected by panic threshold.")
+	flag.IntVar(&flapThreshold, "flap-threshold", 0,
+		"Number of changes of an entry within flap window to suppress it, 0 disables flap damping.")
+	flag.DurationVar(&flapWindow, "flap-window", controllers.DefaultFlapWindow,
+		"Window in which changes of an entry are counted for flap damping.")
+	flag.DurationVar(&flapSuppression, "flap-suppression", controllers.DefaultFlapSuppression,
+		"How long a flapping entry needs to be stable before its changes are published.")
+	flag.DurationVar(&registrationResync, "registration-resync", controllers.DefaultRegistrationResync,
+		"How often registration annotations of services are refreshed when they don't change.")
+	flag.IntVar(&autoProvisionRegionLimit, "auto-provision-region-limit", 0,
+		"Number of regions auto provisioned in a namespace for services annotated with them, 0 disables it.")
+	flag.StringVar(&clusterDomain, "cluster-domain", "",
+		"Cluster domain defaulted to pathfinders, detected from /etc/resolv.conf if not set.")
 	flag.Parse()
 
 	ctrl.SetLogger(zap.New(zap.UseDevMode(true)))
@@ -67,17 +98,42 @@ func main() {
 	}
 
 	if err = (&controllers.PathFinderReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("PathFinder"),
-		Scheme: mgr.GetScheme(),
+		Client:    mgr.GetClient(),
+		APIReader: mgr.GetAPIReader(),
+		Log:       ctrl.Log.WithName("controllers").WithName("PathFinder"),
+		Scheme:    mgr.GetScheme(),
+
+		DrainGracePeriod:   drainGracePeriod,
+		TombstoneRetention: tombstoneRetention,
+		PanicThreshold:     panicThreshold,
+		PanicMinEntries:    panicMinEntries,
+		FlapThreshold:      flapThreshold,
+		FlapWindow:         flapWindow,
+		FlapSuppression:    flapSuppression,
+		RegistrationResync: registrationResync,
+
+		AutoProvisionRegionLimit: autoProvisionRegionLimit,
+		Recorder:                 mgr.GetEventRecorderFor("pathfinder-controller"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "PathFinder")
 		os.Exit(1)
 	}
+	if err = mgr.Add(&controllers.HealthChecker{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("HealthChecker"),
+	}); err != nil {
+		setupLog.Error(err, "unable to create health checker")
+		os.Exit(1)
+	}
+	if len(clusterDomain) == 0 {
+		clusterDomain = pathfinderv1.DetectClusterDomain("/etc/resolv.conf")
+	}
+	pathfinderv1.DefaultClusterDomain = clusterDomain
 	if err = (&pathfinderv1.PathFinder{}).SetupWebhookWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create webhook", "webhook", "PathFinder")
 		os.Exit(1)
 	}
+	controllers.SetupServiceWebhookWithManager(mgr)
 	// +kubebuilder:scaffold:builder
 
 	setupLog.Info("starting manager")
@@ -0,0 +1,129 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+
+	xmbsmdsjv1 "github.com/6BD-org/pathfinder/api/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakePathFinders implements PathFinderInterface
+type FakePathFinders struct {
+	Fake *FakeXmbsmdsjV1
+	ns   string
+}
+
+var pathfindersResource = schema.GroupVersionResource{Group: "xmbsmdsj.com", Version: "v1", Resource: "pathfinders"}
+
+var pathfindersKind = schema.GroupVersionKind{Group: "xmbsmdsj.com", Version: "v1", Kind: "PathFinder"}
+
+// Get takes name of the pathFinder, and returns the corresponding pathFinder object, and an error if there is any.
+func (c *FakePathFinders) Get(ctx context.Context, name string, options v1.GetOptions) (result *xmbsmdsjv1.PathFinder, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewGetAction(pathfindersResource, c.ns, name), &xmbsmdsjv1.PathFinder{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*xmbsmdsjv1.PathFinder), err
+}
+
+// List takes label and field selectors, and returns the list of PathFinders that match those selectors.
+func (c *FakePathFinders) List(ctx context.Context, opts v1.ListOptions) (result *xmbsmdsjv1.PathFinderList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewListAction(pathfindersResource, pathfindersKind, c.ns, opts), &xmbsmdsjv1.PathFinderList{})
+
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &xmbsmdsjv1.PathFinderList{ListMeta: obj.(*xmbsmdsjv1.PathFinderList).ListMeta}
+	for _, item := range obj.(*xmbsmdsjv1.PathFinderList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested pathFinders.
+func (c *FakePathFinders) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewWatchAction(pathfindersResource, c.ns, opts))
+
+}
+
+// Create takes the representation of a pathFinder and creates it.  Returns the server's representation of the pathFinder, and an error, if there is any.
+func (c *FakePathFinders) Create(ctx context.Context, pathFinder *xmbsmdsjv1.PathFinder, opts v1.CreateOptions) (result *xmbsmdsjv1.PathFinder, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewCreateAction(pathfindersResource, c.ns, pathFinder), &xmbsmdsjv1.PathFinder{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*xmbsmdsjv1.PathFinder), err
+}
+
+// Update takes the representation of a pathFinder and updates it. Returns the server's representation of the pathFinder, and an error, if there is any.
+func (c *FakePathFinders) Update(ctx context.Context, pathFinder *xmbsmdsjv1.PathFinder, opts v1.UpdateOptions) (result *xmbsmdsjv1.PathFinder, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateAction(pathfindersResource, c.ns, pathFinder), &xmbsmdsjv1.PathFinder{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*xmbsmdsjv1.PathFinder), err
+}
+
+// Delete takes name of the pathFinder and deletes it. Returns an error if one occurs.
+func (c *FakePathFinders) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewDeleteAction(pathfindersResource, c.ns, name), &xmbsmdsjv1.PathFinder{})
+
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakePathFinders) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	action := testing.NewDeleteCollectionAction(pathfindersResource, c.ns, listOpts)
+
+	_, err := c.Fake.Invokes(action, &xmbsmdsjv1.PathFinderList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched pathFinder.
+func (c *FakePathFinders) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *xmbsmdsjv1.PathFinder, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewPatchSubresourceAction(pathfindersResource, c.ns, name, pt, data, subresources...), &xmbsmdsjv1.PathFinder{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*xmbsmdsjv1.PathFinder), err
+}
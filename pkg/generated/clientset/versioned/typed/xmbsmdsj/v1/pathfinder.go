@@ -0,0 +1,177 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	"context"
+	"time"
+
+	v1 "github.com/6BD-org/pathfinder/api/v1"
+	scheme "github.com/6BD-org/pathfinder/pkg/generated/clientset/versioned/scheme"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// PathFindersGetter has a method to return a PathFinderInterface.
+// A group's client should implement this interface.
+type PathFindersGetter interface {
+	PathFinders(namespace string) PathFinderInterface
+}
+
+// PathFinderInterface has methods to work with PathFinder resources.
+type PathFinderInterface interface {
+	Create(ctx context.Context, pathFinder *v1.PathFinder, opts metav1.CreateOptions) (*v1.PathFinder, error)
+	Update(ctx context.Context, pathFinder *v1.PathFinder, opts metav1.UpdateOptions) (*v1.PathFinder, error)
+	Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (*v1.PathFinder, error)
+	List(ctx context.Context, opts metav1.ListOptions) (*v1.PathFinderList, error)
+	Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (result *v1.PathFinder, err error)
+	PathFinderExpansion
+}
+
+// pathFinders implements PathFinderInterface
+type pathFinders struct {
+	client rest.Interface
+	ns     string
+}
+
+// newPathFinders returns a PathFinders
+func newPathFinders(c *XmbsmdsjV1Client, namespace string) *pathFinders {
+	return &pathFinders{
+		client: c.RESTClient(),
+		ns:     namespace,
+	}
+}
+
+// Get takes name of the pathFinder, and returns the corresponding pathFinder object, and an error if there is any.
+func (c *pathFinders) Get(ctx context.Context, name string, options metav1.GetOptions) (result *v1.PathFinder, err error) {
+	result = &v1.PathFinder{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("pathfinders").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of PathFinders that match those selectors.
+func (c *pathFinders) List(ctx context.Context, opts metav1.ListOptions) (result *v1.PathFinderList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1.PathFinderList{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("pathfinders").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested pathFinders.
+func (c *pathFinders) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Namespace(c.ns).
+		Resource("pathfinders").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch(ctx)
+}
+
+// Create takes the representation of a pathFinder and creates it.  Returns the server's representation of the pathFinder, and an error, if there is any.
+func (c *pathFinders) Create(ctx context.Context, pathFinder *v1.PathFinder, opts metav1.CreateOptions) (result *v1.PathFinder, err error) {
+	result = &v1.PathFinder{}
+	err = c.client.Post().
+		Namespace(c.ns).
+		Resource("pathfinders").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(pathFinder).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Update takes the representation of a pathFinder and updates it. Returns the server's representation of the pathFinder, and an error, if there is any.
+func (c *pathFinders) Update(ctx context.Context, pathFinder *v1.PathFinder, opts metav1.UpdateOptions) (result *v1.PathFinder, err error) {
+	result = &v1.PathFinder{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("pathfinders").
+		Name(pathFinder.Name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(pathFinder).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Delete takes name of the pathFinder and deletes it. Returns an error if one occurs.
+func (c *pathFinders) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("pathfinders").
+		Name(name).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *pathFinders) DeleteCollection(ctx context.Context, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error {
+	var timeout time.Duration
+	if listOpts.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOpts.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("pathfinders").
+		VersionedParams(&listOpts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// Patch applies the patch and returns the patched pathFinder.
+func (c *pathFinders) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (result *v1.PathFinder, err error) {
+	result = &v1.PathFinder{}
+	err = c.client.Patch(pt).
+		Namespace(c.ns).
+		Resource("pathfinders").
+		Name(name).
+		SubResource(subresources...).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return
+}
@@ -0,0 +1,89 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by informer-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	"context"
+	time "time"
+
+	xmbsmdsjv1 "github.com/6BD-org/pathfinder/api/v1"
+	versioned "github.com/6BD-org/pathfinder/pkg/generated/clientset/versioned"
+	internalinterfaces "github.com/6BD-org/pathfinder/pkg/generated/informers/externalversions/internalinterfaces"
+	v1 "github.com/6BD-org/pathfinder/pkg/generated/listers/xmbsmdsj/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	watch "k8s.io/apimachinery/pkg/watch"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// PathFinderInformer provides access to a shared informer and lister for
+// PathFinders.
+type PathFinderInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() v1.PathFinderLister
+}
+
+type pathFinderInformer struct {
+	factory          internalinterfaces.SharedInformerFactory
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+	namespace        string
+}
+
+// NewPathFinderInformer constructs a new informer for PathFinder type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewPathFinderInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers) cache.SharedIndexInformer {
+	return NewFilteredPathFinderInformer(client, namespace, resyncPeriod, indexers, nil)
+}
+
+// NewFilteredPathFinderInformer constructs a new informer for PathFinder type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewFilteredPathFinderInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers, tweakListOptions internalinterfaces.TweakListOptionsFunc) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.XmbsmdsjV1().PathFinders(namespace).List(context.TODO(), options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.XmbsmdsjV1().PathFinders(namespace).Watch(context.TODO(), options)
+			},
+		},
+		&xmbsmdsjv1.PathFinder{},
+		resyncPeriod,
+		indexers,
+	)
+}
+
+func (f *pathFinderInformer) defaultInformer(client versioned.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	return NewFilteredPathFinderInformer(client, f.namespace, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, f.tweakListOptions)
+}
+
+func (f *pathFinderInformer) Informer() cache.SharedIndexInformer {
+	return f.factory.InformerFor(&xmbsmdsjv1.PathFinder{}, f.defaultInformer)
+}
+
+func (f *pathFinderInformer) Lister() v1.PathFinderLister {
+	return v1.NewPathFinderLister(f.Informer().GetIndexer())
+}
@@ -0,0 +1,98 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	v1 "github.com/6BD-org/pathfinder/api/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// PathFinderLister helps list PathFinders.
+// All objects returned here must be treated as read-only.
+type PathFinderLister interface {
+	// List lists all PathFinders in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1.PathFinder, err error)
+	// PathFinders returns an object that can list and get PathFinders.
+	PathFinders(namespace string) PathFinderNamespaceLister
+	PathFinderListerExpansion
+}
+
+// pathFinderLister implements the PathFinderLister interface.
+type pathFinderLister struct {
+	indexer cache.Indexer
+}
+
+// NewPathFinderLister returns a new PathFinderLister.
+func NewPathFinderLister(indexer cache.Indexer) PathFinderLister {
+	return &pathFinderLister{indexer: indexer}
+}
+
+// List lists all PathFinders in the indexer.
+func (s *pathFinderLister) List(selector labels.Selector) (ret []*v1.PathFinder, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1.PathFinder))
+	})
+	return ret, err
+}
+
+// PathFinders returns an object that can list and get PathFinders.
+func (s *pathFinderLister) PathFinders(namespace string) PathFinderNamespaceLister {
+	return pathFinderNamespaceLister{indexer: s.indexer, namespace: namespace}
+}
+
+// PathFinderNamespaceLister helps list and get PathFinders.
+// All objects returned here must be treated as read-only.
+type PathFinderNamespaceLister interface {
+	// List lists all PathFinders in the indexer for a given namespace.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1.PathFinder, err error)
+	// Get retrieves the PathFinder from the indexer for a given namespace and name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*v1.PathFinder, error)
+	PathFinderNamespaceListerExpansion
+}
+
+// pathFinderNamespaceLister implements the PathFinderNamespaceLister
+// interface.
+type pathFinderNamespaceLister struct {
+	indexer   cache.Indexer
+	namespace string
+}
+
+// List lists all PathFinders in the indexer for a given namespace.
+func (s pathFinderNamespaceLister) List(selector labels.Selector) (ret []*v1.PathFinder, err error) {
+	err = cache.ListAllByNamespace(s.indexer, s.namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1.PathFinder))
+	})
+	return ret, err
+}
+
+// Get retrieves the PathFinder from the indexer for a given namespace and name.
+func (s pathFinderNamespaceLister) Get(name string) (*v1.PathFinder, error) {
+	obj, exists, err := s.indexer.GetByKey(s.namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1.Resource("pathfinder"), name)
+	}
+	return obj.(*v1.PathFinder), nil
+}